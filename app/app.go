@@ -1,24 +1,49 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/abhijitWakchaure/run-flogo-app/config"
 	"github.com/abhijitWakchaure/run-flogo-app/files"
+	"github.com/abhijitWakchaure/run-flogo-app/probe"
+	"github.com/abhijitWakchaure/run-flogo-app/process"
+	"github.com/abhijitWakchaure/run-flogo-app/sandbox"
 	"github.com/abhijitWakchaure/run-flogo-app/software"
-	"github.com/spf13/viper"
 )
 
 // App holds the environment variables for the user
 type App struct {
 	*config.AppConfig
 	*software.UpdateConfig
-	InstallPath string
+}
+
+// RunOptions holds the options used to execute a flogo app
+type RunOptions struct {
+	LogLevel string
+	Env      []string
+	Args     []string
+	// Ports are checked to be free before the launch, the trigger ports of
+	// the app are checked if not set
+	Ports []int
+	// Ready waits for the app to be ready if set
+	Ready *ReadyOptions
+	// Detach runs the app in the background
+	Detach bool
+	// Stats monitors the resource usage of the app if set
+	Stats *StatsOptions
+	// Container runs the app in a container if set, with the limits of
+	// Sandbox
+	Container *ContainerOptions
+	// Sandbox runs the app with limits if set
+	Sandbox *sandbox.Options
 }
 
 // NewApp ...
@@ -26,77 +51,138 @@ func NewApp(appConfig *config.AppConfig, updateConfig *software.UpdateConfig) *A
 	a := new(App)
 	a.AppConfig = appConfig
 	a.UpdateConfig = updateConfig
-	var appPattern string
-	switch runtime.GOOS {
-	case "linux":
-		appPattern = config.DefaultAppPatternLinux
-		a.InstallPath = config.InstallPathLinux
-	case "windows":
-		appPattern = config.DefaultAppPatternWindows
-		a.InstallPath = config.InstallPathWindows
-	case "darwin":
-		appPattern = config.DefaultAppPatternDarwin
-		a.InstallPath = config.InstallPathDarwin
-	default:
+	defaults := DefaultAppConfig()
+	if defaults.AppPattern == "" {
 		fmt.Printf("\nError: OS %s is not yet supported, please contact developers\n", runtime.GOOS)
-		os.Exit(1)
+		Exit(1)
 	}
+	// Only write the config when a default had to be filled in, the config
+	// file is left as is otherwise
+	changed := false
 	if a.AppPattern == "" {
-		a.AppPattern = appPattern
+		a.AppPattern = defaults.AppPattern
+		changed = true
 	}
 	if a.AppsDir == "" {
-		a.AppsDir = filepath.Join(config.GetUserHomeDir(), "Downloads")
+		a.AppsDir = defaults.AppsDir
+		changed = true
+	}
+	if a.SortBy == "" {
+		a.SortBy = defaults.SortBy
+		changed = true
+	}
+	if changed {
+		WriteAppConfig(a.AppConfig)
 	}
-	WriteAppConfig(a.AppConfig)
 	return a
 }
 
+// DefaultAppConfig returns the config used for the keys which are not set,
+// the app pattern is empty if the OS is not supported
+func DefaultAppConfig() *config.AppConfig {
+	c := &config.AppConfig{
+		AppsDir: filepath.Join(config.GetUserHomeDir(), "Downloads"),
+		SortBy:  config.DefaultSortBy,
+	}
+	switch runtime.GOOS {
+	case "linux":
+		c.AppPattern = config.DefaultAppPatternLinux
+	case "windows":
+		c.AppPattern = config.DefaultAppPatternWindows
+	case "darwin":
+		c.AppPattern = config.DefaultAppPatternDarwin
+	}
+	return c
+}
+
 // PrintConfig will print the app config
 func (a *App) PrintConfig() {
 	c := &config.AppConfig{
 		AppsDir:    a.AppsDir,
 		AppPattern: a.AppPattern,
+		SortBy:     a.SortBy,
 	}
 	config.Print(c)
 }
 
 // WriteAppConfig will write the app config
 func WriteAppConfig(appConfig *config.AppConfig) {
-	viper.Set("appsDir", appConfig.AppsDir)
-	viper.Set("appPattern", appConfig.AppPattern)
-	viper.WriteConfig()
+	writeSettings(func(m map[string]interface{}) {
+		m["appsDir"] = appConfig.AppsDir
+		m["appPattern"] = appConfig.AppPattern
+		m["sortBy"] = appConfig.SortBy
+	})
+}
+
+// WriteConfigValue will write the value of a single key into the config file
+func WriteConfigValue(key string, value interface{}) {
+	writeSettings(func(m map[string]interface{}) {
+		m[key] = value
+	})
+}
+
+// UnsetConfigValue will remove the key from the config file, the default
+// value will be used for it from the next run
+func UnsetConfigValue(key string) {
+	writeSettings(func(m map[string]interface{}) {
+		for k := range m {
+			if strings.EqualFold(k, key) {
+				delete(m, k)
+			}
+		}
+	})
+}
+
+// UpdateAliases will apply fn on the aliases saved in the config file, fn is
+// called with the latest content of the file while holding the lock on it
+func UpdateAliases(fn func(aliases map[string]interface{})) {
+	writeSettings(func(m map[string]interface{}) {
+		aliases, _ := m["aliases"].(map[string]interface{})
+		if aliases == nil {
+			aliases = map[string]interface{}{}
+		}
+		fn(aliases)
+		m["aliases"] = aliases
+	})
+}
+
+func writeSettings(fn func(m map[string]interface{})) {
+	err := config.UpdateSettings(fn)
+	if err != nil {
+		fmt.Printf("E> Error ERR_WRITE_CONFIG: %s\n", err.Error())
+	}
 }
 
 // RunLatestApp will run the latest app
-func (a *App) RunLatestApp(logLevel string, args []string) {
-	latestFlogoApp := files.FindLatestApp(a.AppsDir, a.AppPattern)
+func (a *App) RunLatestApp(opts *RunOptions) {
+	latestFlogoApp := files.FindLatestApp(a.AppsDir, a.AppPattern, a.SortBy)
 	if len(latestFlogoApp) == 0 {
-		os.Exit(1)
+		Exit(1)
 	}
 	fmt.Printf("#> Do you want to execute the app '%s' [y/n]: ", latestFlogoApp)
 	choice := software.HandleYNInput()
 	if !choice {
-		os.Exit(0)
+		Exit(0)
 	}
-	runExecutable(latestFlogoApp, logLevel, args)
+	runExecutable(latestFlogoApp, opts)
 }
 
 // RunNamedApp will run the app with given (partial) name
 // If there are multiple matches, it will ask for user to choose
-func (a *App) RunNamedApp(name, logLevel string, args []string) {
-	flogoApps := files.FindAppsWithName(a.AppsDir, a.AppPattern, name)
+func (a *App) RunNamedApp(name string, opts *RunOptions) {
+	flogoApps := files.FindAppsWithName(a.AppsDir, a.AppPattern, name, a.SortBy)
 	if len(flogoApps) == 0 {
 		fmt.Printf("\n#> No flogo apps found containing name [%s] in apps dir [%s]\n", name, a.AppsDir)
-		os.Exit(1)
+		Exit(1)
 	}
 	if len(flogoApps) == 1 {
 		flogoApp := flogoApps[0]
 		fmt.Printf("#> Do you want to execute the app '%s' [y/n]: ", flogoApp)
 		choice := software.HandleYNInput()
 		if !choice {
-			os.Exit(0)
+			Exit(0)
 		}
-		runExecutable(flogoApp, logLevel, args)
+		runExecutable(flogoApp, opts)
 	}
 	fmt.Printf("#> Got %d matches for query [%s]:\n", len(flogoApps), name)
 	for i, v := range flogoApps {
@@ -106,27 +192,27 @@ func (a *App) RunNamedApp(name, logLevel string, args []string) {
 	choice := software.HandleNumericInput()
 	if choice < 1 || choice > len(flogoApps) {
 		fmt.Printf("\nE> Invalid choice, please choose a number between 1 and %d\n", len(flogoApps))
-		os.Exit(1)
+		Exit(1)
 	}
 	flogoApp := flogoApps[choice-1]
-	runExecutable(flogoApp, logLevel, args)
+	runExecutable(flogoApp, opts)
 }
 
 // RunWithList will list the last 5 apps and will ask user to select 1
-func (a *App) RunWithList(logLevel string, args []string) {
-	flogoApps := files.ListLastNApps(a.AppsDir, a.AppPattern, config.MaxAppsWithList)
+func (a *App) RunWithList(opts *RunOptions) {
+	flogoApps := files.ListLastNApps(a.AppsDir, a.AppPattern, a.SortBy, config.MaxAppsWithList)
 	if len(flogoApps) == 0 {
 		fmt.Printf("\n#> No flogo apps found in apps dir [%s]\n", a.AppsDir)
-		os.Exit(1)
+		Exit(1)
 	}
 	if len(flogoApps) == 1 {
 		flogoApp := flogoApps[0]
 		fmt.Printf("#> Do you want to execute the app '%s' [y/n]: ", flogoApp)
 		choice := software.HandleYNInput()
 		if !choice {
-			os.Exit(0)
+			Exit(0)
 		}
-		runExecutable(flogoApp, logLevel, args)
+		runExecutable(flogoApp, opts)
 	}
 	fmt.Printf("#> Here is the list of apps:\n")
 	for i, v := range flogoApps {
@@ -136,46 +222,165 @@ func (a *App) RunWithList(logLevel string, args []string) {
 	choice := software.HandleNumericInput()
 	if choice < 1 || choice > len(flogoApps) {
 		fmt.Printf("\nE> Invalid choice, please choose a number between 1 and %d\n", len(flogoApps))
-		os.Exit(1)
+		Exit(1)
 	}
 	flogoApp := flogoApps[choice-1]
-	runExecutable(flogoApp, logLevel, args)
+	runExecutable(flogoApp, opts)
 }
 
-// Update will update the app to latest version released on Github
-func (a *App) Update() {
-	software.Update(a.AppConfig)
+// Update will update the app to latest version released in the channel, or
+// to the given version if it is not empty
+func (a *App) Update(version, channel string, allowUnsigned bool) {
+	src := software.NewReleaseSource(a.AppConfig)
+	src.Version = version
+	src.AllowUnsigned = allowUnsigned
+	if channel != "" {
+		src.Channel = channel
+	}
+	software.Update(a.AppConfig, src)
 }
 
 // PrintVersion ...
-func PrintVersion() {
+func PrintVersion(updateConfig *software.UpdateConfig) {
 	fmt.Println("#> Run Flogo App")
-	fmt.Println("#> Version:", config.VERSION)
+	fmt.Println("#> Version:", software.DescribeVersion(config.VERSION))
+	if updateConfig != nil && updateConfig.LatestVersion != "" {
+		status := software.GetVersionStatus(config.VERSION, updateConfig.LatestVersion)
+		fmt.Printf("#> Latest release: %s (%s)\n", updateConfig.LatestVersion, status.Message(updateConfig.LatestVersion))
+	}
 	fmt.Println("#> Developer: Abhijit Wakchaure")
 	fmt.Println("#> Github:", config.GithubBaseURL)
 }
 
-func runExecutable(path, logLevel string, args []string) {
+func runExecutable(path string, opts *RunOptions) {
 	fmt.Println("\n#> Making app executable...")
 	err := os.Chmod(path, 0700)
 	if err != nil {
 		fmt.Printf("\nE> Error ERR_MAKE_APP_EXEC: %s\n", err.Error())
-		os.Exit(1)
+		Exit(1)
+	}
+	base, env := runEnv(opts)
+	tps, portsErr := appPorts(path, env, opts.Ports)
+	if opts.Container == nil && opts.Sandbox != nil && opts.Sandbox.Isolates("net") {
+		fmt.Println("W> The app runs in its own network namespace, its ports cannot be reached from the host")
+	} else if portsErr == nil {
+		env = checkPorts(tps, env)
+	}
+	if opts.Container == nil && opts.Sandbox != nil {
+		fmt.Printf("#> Running the app with %s\n", opts.Sandbox)
+		if opts.Sandbox.UsesCgroup() {
+			OnExit(sandbox.Cleanup)
+		}
+	}
+	var ready *readiness
+	if opts.Ready != nil {
+		ready, err = newReadiness(tps, portsErr, opts.Ready)
+		if err != nil {
+			fmt.Printf("\nE> Error ERR_READY_PROBE: %s\n", err.Error())
+			Exit(1)
+		}
+		fmt.Printf("#> Waiting up to %s for the app to be ready (%s)\n", opts.Ready.Timeout, ready)
+	}
+	if opts.Detach {
+		if opts.Stats != nil {
+			fmt.Println("W> --stats is ignored with --detach, see the resource usage with 'run-flogo-app stats'")
+		}
+		// Only the variables added on top of the environment are kept in the registry
+		runDetached(path, opts, env[len(base):], ready)
+	}
+	var cmd *exec.Cmd
+	if opts.Container != nil {
+		if opts.Stats != nil {
+			fmt.Println("W> --stats is ignored with --container, see the resource usage with the container runtime")
+			opts.Stats = nil
+		}
+		cmd, err = containerCommand(path, opts, base, env, tps)
+		if err != nil {
+			fmt.Printf("\nE> Error ERR_CONTAINER: %s\n", err.Error())
+			Exit(1)
+		}
+	} else {
+		cmd, err = sandbox.Command(path, opts.Args, env, opts.Sandbox)
+		if err != nil {
+			fmt.Printf("\nE> Error ERR_SANDBOX: %s\n", err.Error())
+			Exit(1)
+		}
 	}
-	cmd := exec.Command(path, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	cmd.Env = os.Environ()
-	fmt.Printf("#> Executing: %s\n\n", strings.Join(cmd.Args, " "))
-	if logLevel != config.LogLevelInfo {
-		logLevelEnv := fmt.Sprintf("FLOGO_LOG_LEVEL=%s", logLevel)
-		cmd.Env = append(cmd.Env, logLevelEnv)
+	if ready != nil && ready.log != nil {
+		cmd.Stdout = io.MultiWriter(os.Stdout, ready.log)
+		cmd.Stderr = io.MultiWriter(os.Stderr, ready.log)
 	}
-	err = cmd.Run()
+	executed := append([]string{path}, opts.Args...)
+	if opts.Container != nil {
+		executed = cmd.Args
+	}
+	fmt.Printf("#> Executing: %s\n\n", strings.Join(executed, " "))
+	err = cmd.Start()
 	if err != nil {
 		fmt.Printf("\nE> Error ERR_RUN_FA: %s\n", err.Error())
-		os.Exit(1)
+		Exit(1)
+	}
+	process.Record(&process.Event{Action: process.ActionRun, PID: cmd.Process.Pid, Name: filepath.Base(path), Path: path, Args: opts.Args})
+	if opts.Stats != nil {
+		forwardSignals(cmd.Process)
+		startStats(cmd.Process.Pid, env, opts.Stats)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	if ready != nil {
+		waitReady(cmd, ready, opts.Ready.Timeout, exited)
+	}
+	err = <-exited
+	if err != nil {
+		fmt.Printf("\nE> Error ERR_RUN_FA: %s\n", err.Error())
+		Exit(1)
+	}
+	Exit(0)
+}
+
+// runEnv returns the environment of the program and the environment for the
+// app, which has the variables set by opts on top of it
+func runEnv(opts *RunOptions) (base, env []string) {
+	base = sandbox.Environ(opts.Sandbox)
+	env = base
+	if opts.LogLevel != config.LogLevelInfo {
+		logLevelEnv := fmt.Sprintf("FLOGO_LOG_LEVEL=%s", opts.LogLevel)
+		env = append(env, logLevelEnv)
+	}
+	return base, append(env, opts.Env...)
+}
+
+// waitReady waits for the probes to pass, the app is killed if it is not
+// ready within the timeout
+func waitReady(cmd *exec.Cmd, ready *readiness, timeout time.Duration, exited chan error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		result <- probe.Wait(ctx, ready.probes, readyInterval)
+	}()
+	select {
+	case err := <-result:
+		if err != nil {
+			fmt.Printf("\nE> Error ERR_APP_NOT_READY: app is not ready after %s, last failure: %s\n", timeout, err.Error())
+			cmd.Process.Kill()
+			<-exited
+			Exit(1)
+		}
+		fmt.Printf("\n#> App is ready in %s\n\n", time.Since(start).Round(time.Millisecond))
+	case err := <-exited:
+		cancel()
+		reason := "exit status 0"
+		if err != nil {
+			reason = err.Error()
+		}
+		fmt.Printf("\nE> Error ERR_APP_NOT_READY: app exited before it was ready (%s)\n", reason)
+		Exit(1)
 	}
-	os.Exit(0)
 }
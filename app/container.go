@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/container"
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+)
+
+// ContainerImageDefault selects the image set in the config, or else
+// config.DefaultContainerImage
+const ContainerImageDefault = "default"
+
+// ContainerOptions holds the options to run the app in a container
+type ContainerOptions struct {
+	Image string
+	// Runtime is the name or path of the container runtime, the first one of
+	// container.Runtimes found is used if empty
+	Runtime string
+}
+
+// containerCommand returns the command running the app in a container, with
+// the trigger ports published and the variables set for the app passed to it
+func containerCommand(path string, opts *RunOptions, base, env []string, tps []*flogo.TriggerPort) (*exec.Cmd, error) {
+	if err := container.CheckLinuxBinary(path); err != nil {
+		return nil, err
+	}
+	rt, err := container.Detect(opts.Container.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	co := &container.Options{
+		Image: opts.Container.Image,
+		Name:  containerName(path, os.Getpid()),
+		Env:   containerEnv(base, env),
+	}
+	published := map[int]bool{}
+	for _, tp := range tps {
+		if !published[tp.Port] {
+			published[tp.Port] = true
+			co.Ports = append(co.Ports, tp.Port)
+		}
+	}
+	if sb := opts.Sandbox; sb != nil {
+		if len(sb.Namespaces) > 0 || sb.Cgroup {
+			fmt.Println("W> --isolate and --cgroup are ignored with --container, which isolates the app")
+		}
+		if sb.Workdir != "" {
+			if err := os.MkdirAll(sb.Workdir, 0755); err != nil {
+				return nil, err
+			}
+		}
+		co.Workdir, co.Memory, co.CPUs, co.PIDs = sb.Workdir, sb.Memory, sb.CPUs, sb.PIDs
+		co.OpenFiles, co.CPUTime = sb.OpenFiles, sb.CPUTime
+	}
+	fmt.Printf("#> Running the app in a %s container of image %s", rt.Name, co.Image)
+	if len(co.Ports) > 0 {
+		fmt.Printf(", publishing ports %s", strings.Trim(fmt.Sprint(co.Ports), "[]"))
+	}
+	fmt.Println()
+	// The container is left running if the runtime is killed, e.g. when the
+	// app is not ready in time, it is already removed if it has exited
+	OnExit(func() {
+		rt.Remove(co.Name)
+	})
+	// The runtime runs with the environment of the program, the container
+	// only gets the variables set for the app
+	return rt.Command(path, opts.Args, append(os.Environ(), env[len(base):]...), co), nil
+}
+
+// containerName returns the name of the container of the app, the app name is
+// cleaned like deploy.dnsLabel as the runtimes only accept [a-zA-Z0-9][a-zA-Z0-9_.-]*
+func containerName(path string, pid int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := b.String()
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	name = strings.Trim(name, "-")
+	if name == "" {
+		return fmt.Sprintf("run-flogo-app-%d", pid)
+	}
+	return fmt.Sprintf("run-flogo-app-%s-%d", name, pid)
+}
+
+// containerEnv returns the names of the variables passed to the container,
+// the ones set for the app and the flogo ones of the environment
+func containerEnv(base, env []string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for i, e := range env {
+		name := strings.SplitN(e, "=", 2)[0]
+		if seen[name] || i < len(base) && !strings.HasPrefix(name, "FLOGO_") {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,20 @@
+package app
+
+import "os"
+
+// exitHooks are run before the program exits with Exit
+var exitHooks []func()
+
+// OnExit registers fn to be run before the program exits with Exit, the hooks
+// are run in the reverse order of their registration
+func OnExit(fn func()) {
+	exitHooks = append(exitHooks, fn)
+}
+
+// Exit runs the exit hooks and exits the program with the given code
+func Exit(code int) {
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i]()
+	}
+	os.Exit(code)
+}
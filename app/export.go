@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/deploy"
+	"github.com/abhijitWakchaure/run-flogo-app/files"
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+	"github.com/abhijitWakchaure/run-flogo-app/software"
+)
+
+// Profile holds the settings the app is run with, which are carried to its
+// deployment files and images
+type Profile struct {
+	LogLevel string
+	Env      []string
+	Ports    []int
+	Args     []string
+}
+
+// ExportOptions holds the deployment files to export
+type ExportOptions struct {
+	Format string
+	// Output is the dir the files are written to
+	Output string
+	// Image is the image of the app, the default is <name>:<version>
+	Image string
+	// BaseImage is the image the Dockerfile builds on
+	BaseImage string
+	Profile
+}
+
+// Export writes the deployment file of the app with the (partial) name, or
+// of the latest app if name is empty
+func (a *App) Export(name string, opts *ExportOptions) {
+	path := a.findApp(name)
+	m := opts.Profile.model(path)
+	m.BaseImage = opts.BaseImage
+	m.Generator = config.AppName + " " + config.VERSION
+	if opts.Image != "" {
+		m.Image = opts.Image
+	}
+	if err := os.MkdirAll(opts.Output, 0755); err != nil {
+		fmt.Printf("E> Error ERR_EXPORT: %s\n", err.Error())
+		Exit(1)
+	}
+	out := filepath.Join(opts.Output, deploy.FileName(opts.Format))
+	if !confirmOverwrite(out) {
+		Exit(0)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		fmt.Printf("E> Error ERR_EXPORT: %s\n", err.Error())
+		Exit(1)
+	}
+	err = m.Render(f, opts.Format)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Printf("E> Error ERR_EXPORT: %s\n", err.Error())
+		Exit(1)
+	}
+	fmt.Printf("#> Exported the %s file of %s to: %s\n", opts.Format, filepath.Base(path), out)
+	// The app is copied to the build context of the Dockerfile
+	if opts.Format == "docker" {
+		binary := filepath.Join(opts.Output, filepath.Base(path))
+		if err := copyApp(path, binary); err != nil {
+			fmt.Printf("E> Error ERR_EXPORT: %s\n", err.Error())
+			Exit(1)
+		}
+		fmt.Printf("#> Build the image with: docker build -t %s %s\n", m.Image, opts.Output)
+	}
+	Exit(0)
+}
+
+// model returns the model of the app at path run with the profile
+func (p *Profile) model(path string) *deploy.Model {
+	env := append([]string{}, p.Env...)
+	if p.LogLevel != config.LogLevelInfo {
+		env = append([]string{"FLOGO_LOG_LEVEL=" + p.LogLevel}, env...)
+	}
+	d, err := flogo.ReadDescriptor(path)
+	if err != nil {
+		fmt.Printf("W> Unable to read the app descriptor, its properties are not known: %s\n", err.Error())
+	}
+	tps, err := appPorts(path, env, p.Ports)
+	if err != nil {
+		fmt.Printf("W> No ports are exposed: %s\n", err.Error())
+	}
+	return deploy.New(path, d, tps, env, p.Args)
+}
+
+// findApp returns the app with the (partial) name, or the latest app if name
+// is empty. If there are multiple matches, it asks the user to choose.
+func (a *App) findApp(name string) string {
+	if name == "" {
+		latestFlogoApp := files.FindLatestApp(a.AppsDir, a.AppPattern, a.SortBy)
+		if len(latestFlogoApp) == 0 {
+			Exit(1)
+		}
+		return latestFlogoApp
+	}
+	flogoApps := files.FindAppsWithName(a.AppsDir, a.AppPattern, name, a.SortBy)
+	if len(flogoApps) == 0 {
+		fmt.Printf("#> No flogo apps found containing name [%s] in apps dir [%s]\n", name, a.AppsDir)
+		Exit(1)
+	}
+	if len(flogoApps) == 1 {
+		return flogoApps[0]
+	}
+	fmt.Printf("#> Got %d matches for query [%s]:\n", len(flogoApps), name)
+	for i, v := range flogoApps {
+		fmt.Printf("%d. %s\n", i+1, filepath.Base(v))
+	}
+	fmt.Printf("\n#> Choose an app [1-%d]: ", len(flogoApps))
+	choice := software.HandleNumericInput()
+	if choice < 1 || choice > len(flogoApps) {
+		fmt.Printf("\nE> Invalid choice, please choose a number between 1 and %d\n", len(flogoApps))
+		Exit(1)
+	}
+	return flogoApps[choice-1]
+}
+
+// confirmOverwrite asks the user before an existing file is overwritten
+func confirmOverwrite(path string) bool {
+	if _, err := os.Stat(path); err != nil {
+		return true
+	}
+	fmt.Printf("#> File '%s' already exists, do you want to overwrite it [y/n]: ", path)
+	return software.HandleYNInput()
+}
+
+// copyApp copies the app to dst, unless it is the same file
+func copyApp(src, dst string) error {
+	if srcInfo, err := os.Stat(src); err == nil {
+		if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(srcInfo, dstInfo) {
+			return nil
+		}
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, 0755); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(dst, 0755)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/artifact"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/files"
+	"github.com/abhijitWakchaure/run-flogo-app/software"
+)
+
+// Fetch will download the app with given (partial) name from the artifact
+// repo into the apps dir. If there are multiple matches, it will ask for
+// user to choose.
+func (a *App) Fetch(name string, force bool) {
+	artifacts := a.listArtifacts(name)
+	if len(artifacts) == 0 {
+		fmt.Printf("\n#> No flogo apps found containing name [%s] in artifact repo [%s]\n", name, a.ArtifactRepo)
+		Exit(1)
+	}
+	target := artifacts[0]
+	if len(artifacts) > 1 {
+		fmt.Printf("#> Got %d matches for query [%s]:\n", len(artifacts), name)
+		printArtifacts(artifacts, a.AppsDir)
+		fmt.Printf("\n#> Choose an app that you want to fetch [1-%d]: ", len(artifacts))
+		choice := software.HandleNumericInput()
+		if choice < 1 || choice > len(artifacts) {
+			fmt.Printf("\nE> Invalid choice, please choose a number between 1 and %d\n", len(artifacts))
+			Exit(1)
+		}
+		target = artifacts[choice-1]
+	}
+	if !force && artifact.IsFetched(target, a.AppsDir) {
+		fmt.Printf("#> %s is already fetched into apps dir [%s], use --force to fetch it again\n", target.Name, a.AppsDir)
+	} else {
+		fmt.Printf("#> Fetching %s from %s\n", target.Name, target.URL)
+		_, err := artifact.Fetch(context.Background(), target, a.ArtifactRepo, a.AppsDir)
+		if err != nil {
+			fmt.Printf("\nE> Error ERR_FETCH: %s\n", err.Error())
+			Exit(1)
+		}
+		fmt.Printf("#> Fetched %s into apps dir [%s]\n", target.Name, a.AppsDir)
+	}
+	runName := target.Name
+	if strings.ContainsAny(runName, " \t'\"") {
+		runName = strconv.Quote(runName)
+	}
+	fmt.Printf("#> You can run it with: %s -n %s\n", config.AppName, runName)
+}
+
+// ListArtifacts will print the apps in the artifact repo containing the (partial) name
+func (a *App) ListArtifacts(name string) {
+	artifacts := a.listArtifacts(name)
+	if len(artifacts) == 0 {
+		fmt.Printf("#> No flogo apps found in artifact repo [%s]\n", a.ArtifactRepo)
+		return
+	}
+	fmt.Printf("#> Flogo apps in artifact repo [%s]:\n", a.ArtifactRepo)
+	printArtifacts(artifacts, a.AppsDir)
+}
+
+func (a *App) listArtifacts(name string) []*artifact.Artifact {
+	if a.ArtifactRepo == "" {
+		fmt.Printf("E> Error ERR_FETCH_NOREPO: no artifact repo is configured, set it with: %s config set artifactRepo <url>\n", config.AppName)
+		Exit(1)
+	}
+	fmt.Printf("#> Listing apps in artifact repo [%s]...\n", a.ArtifactRepo)
+	artifacts, err := artifact.List(context.Background(), a.ArtifactRepo)
+	if err != nil {
+		fmt.Printf("\nE> Error ERR_FETCH_LIST: %s\n", err.Error())
+		Exit(1)
+	}
+	return artifact.Filter(artifacts, files.CompilePattern(a.AppPattern), name)
+}
+
+func printArtifacts(artifacts []*artifact.Artifact, appsDir string) {
+	for i, art := range artifacts {
+		line := fmt.Sprintf("%d. %s", i+1, art.Name)
+		if art.Version != "" {
+			line += " (" + art.Version + ")"
+		}
+		if artifact.IsFetched(art, appsDir) {
+			line += " [fetched]"
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"debug/elf"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/monitor"
+	"github.com/abhijitWakchaure/run-flogo-app/oci"
+)
+
+// ImageOptions holds how the image of the app is built
+type ImageOptions struct {
+	// Output is the tarball, if it ends with .tar, or the dir of the image
+	// layout, the default is <app name>.tar
+	Output string
+	// Base is oci.Scratch, an image layout or an image of a registry
+	Base string
+	// Image is the name of the image, the default is <name>:<version>
+	Image      string
+	Entrypoint []string
+	User       string
+	// CACerts are the PEM files added to the image, the CAs of the system
+	// are used if nil
+	CACerts []string
+	Files   []oci.File
+	Profile
+}
+
+// BuildImage builds the OCI image of the app with the (partial) name, or of
+// the latest app if name is empty
+func (a *App) BuildImage(name string, opts *ImageOptions) {
+	path := a.findApp(name)
+	m := opts.Profile.model(path)
+	if opts.Image != "" {
+		m.Image = opts.Image
+	}
+	out := opts.Output
+	if out == "" {
+		out = m.Name + ".tar"
+	}
+	if !confirmOverwrite(out) {
+		Exit(0)
+	}
+	if opts.Base == oci.Scratch && isDynamic(path) {
+		fmt.Println("W> The app is dynamically linked, it needs a --base image with its libc")
+	}
+	created := time.Now().UTC().Truncate(time.Second)
+	// Like the other build tools, the time can be fixed for reproducible builds
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		created = time.Unix(epoch, 0).UTC()
+	}
+	o := &oci.Options{
+		Base:       opts.Base,
+		App:        path,
+		Name:       m.Image,
+		Entrypoint: opts.Entrypoint,
+		Cmd:        m.Args,
+		User:       opts.User,
+		CACerts:    opts.CACerts,
+		Files:      opts.Files,
+		Created:    created,
+		CacheDir:   filepath.Join(config.CacheDir(), "images"),
+		Labels: map[string]string{
+			"org.opencontainers.image.title":   m.AppName,
+			"org.opencontainers.image.version": m.Version,
+			"org.opencontainers.image.created": created.Format(time.RFC3339),
+		},
+	}
+	if o.CACerts == nil {
+		o.CACerts = systemCACerts(a.CABundle)
+		if len(o.CACerts) == 0 {
+			fmt.Println("W> No CA certificates found on this system, use --ca-certs to add them to the image")
+		}
+	}
+	for _, e := range m.Env {
+		o.Env = append(o.Env, e.Name+"="+e.Value)
+	}
+	for _, p := range m.Ports {
+		o.Ports = append(o.Ports, p.Port)
+	}
+	fmt.Printf("#> Building the image %s of %s on %s\n", m.Image, filepath.Base(path), opts.Base)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	res, err := oci.Build(ctx, out, o)
+	if err != nil {
+		fmt.Printf("E> Error ERR_BUILD_IMAGE: %s\n", err.Error())
+		Exit(1)
+	}
+	kind := "image layout"
+	if strings.HasSuffix(strings.ToLower(out), ".tar") {
+		kind = "image tarball"
+	}
+	fmt.Printf("#> Wrote the %s for %s to: %s\n", kind, res.Platform, out)
+	layers := fmt.Sprintf("%d layers", res.Layers)
+	if res.Layers == 1 {
+		layers = "1 layer"
+	}
+	fmt.Printf("#> Digest: %s, %s, %s\n", res.Digest, layers, monitor.FormatBytes(res.Size))
+	if kind == "image tarball" {
+		fmt.Printf("#> Load it with: docker load -i %s\n", out)
+	} else {
+		fmt.Printf("#> Push it with: skopeo copy oci:%s docker://%s\n", out, m.Image)
+	}
+	Exit(0)
+}
+
+// systemCACerts returns the CA bundle of the system along with the CA bundle
+// of the config, which is trusted by the program as well
+func systemCACerts(caBundle string) []string {
+	var certs []string
+	for _, c := range oci.SystemCACerts {
+		if fi, err := os.Stat(c); err == nil && !fi.IsDir() {
+			certs = append(certs, c)
+			break
+		}
+	}
+	if caBundle != "" {
+		certs = append(certs, caBundle)
+	}
+	return certs
+}
+
+// isDynamic returns true if the executable at path needs a dynamic loader
+func isDynamic(path string) bool {
+	f, err := elf.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	for _, p := range f.Progs {
+		if p.Type == elf.PT_INTERP {
+			return true
+		}
+	}
+	return false
+}
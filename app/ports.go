@@ -0,0 +1,114 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+	"github.com/abhijitWakchaure/run-flogo-app/ports"
+	"github.com/abhijitWakchaure/run-flogo-app/software"
+)
+
+// killTimeout is how long the process holding a port has to stop before it is killed
+const killTimeout = 5 * time.Second
+
+// appPorts returns the configured ports, or else the trigger ports of the app
+// at path resolved with env
+func appPorts(path string, env []string, configured []int) ([]*flogo.TriggerPort, error) {
+	if len(configured) > 0 {
+		var tps []*flogo.TriggerPort
+		for _, port := range configured {
+			tps = append(tps, &flogo.TriggerPort{Port: port})
+		}
+		return tps, nil
+	}
+	d, err := flogo.ReadDescriptor(path)
+	if err != nil {
+		return nil, err
+	}
+	tps := d.TriggerPorts(env)
+	if len(tps) == 0 {
+		return nil, fmt.Errorf("no trigger with a port found in the app")
+	}
+	return tps, nil
+}
+
+// checkPorts makes sure the ports are free before the app is launched. For a
+// port in use, the user can kill the process holding it or move the trigger
+// to a free port if the port is set from an app property. It returns env
+// with the property overrides.
+func checkPorts(tps []*flogo.TriggerPort, env []string) []string {
+	used := map[int]bool{}
+	for _, tp := range tps {
+		used[tp.Port] = true
+	}
+	checked := map[int]bool{}
+	for _, tp := range tps {
+		if checked[tp.Port] {
+			continue
+		}
+		checked[tp.Port] = true
+		err := ports.Check(tp.Port)
+		if err == nil {
+			continue
+		}
+		port := tp.Port
+		fmt.Printf("\nW> Port %s is not available: %s\n", tp, err.Error())
+		holder, err := ports.FindHolder(port)
+		if err != nil {
+			fmt.Printf("#> Unable to find the process holding the port: %s\n", err.Error())
+		} else {
+			fmt.Printf("#> The port is held by %s\n", holder)
+		}
+		var choices []string
+		kill, remap := -1, -1
+		if holder != nil {
+			kill = len(choices)
+			choices = append(choices, fmt.Sprintf("Stop the process %d", holder.PID))
+		}
+		free := 0
+		if tp.Property != "" {
+			free = ports.Free(port, used)
+			if free > 0 {
+				remap = len(choices)
+				choices = append(choices, fmt.Sprintf("Run the app on port %d by overriding the app property %s", free, tp.Property))
+			}
+		}
+		choices = append(choices, "Run the app anyway", "Abort")
+		for i, c := range choices {
+			fmt.Printf("%d. %s\n", i+1, c)
+		}
+		fmt.Printf("\n#> Choose what to do [1-%d]: ", len(choices))
+		choice := software.HandleNumericInput() - 1
+		// The choices not offered are -1, which must not match a choice of 0
+		switch {
+		case choice < 0 || choice >= len(choices):
+			fmt.Printf("\nE> Invalid choice, please choose a number between 1 and %d\n", len(choices))
+			Exit(1)
+		case kill >= 0 && choice == kill:
+			fmt.Printf("\n#> Stopping the process %d...\n", holder.PID)
+			if err := ports.Kill(holder, port, killTimeout); err != nil {
+				fmt.Printf("\nE> Error ERR_KILL_PORT_HOLDER: unable to free port %d: %s\n", port, err.Error())
+				Exit(1)
+			}
+			fmt.Printf("#> Port %d is free now\n", port)
+		case remap >= 0 && choice == remap:
+			env, err = flogo.SetProperty(env, tp.Property, free)
+			if err != nil {
+				fmt.Printf("\nE> Error ERR_REMAP_PORT: %s\n", err.Error())
+				Exit(1)
+			}
+			used[free] = true
+			for _, other := range tps {
+				if other.Property == tp.Property {
+					other.Port = free
+				}
+			}
+			fmt.Printf("\n#> The app will listen on port %d, set with %s={\"%s\":%d}\n", free, flogo.EnvPropsJSON, tp.Property, free)
+		case choice == len(choices)-2:
+		case choice == len(choices)-1:
+			Exit(0)
+		}
+	}
+	return env
+}
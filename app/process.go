@@ -0,0 +1,188 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+	"github.com/abhijitWakchaure/run-flogo-app/ports"
+	"github.com/abhijitWakchaure/run-flogo-app/process"
+)
+
+// runDetached starts the app in the background and exits once it is started,
+// or once it is ready if there are probes
+func runDetached(path string, opts *RunOptions, env []string, ready *readiness) {
+	dir, _ := os.Getwd()
+	inst := &process.Instance{Path: path, Args: opts.Args, Env: env, Dir: dir, Sandbox: opts.Sandbox}
+	fmt.Printf("#> Executing in the background: %s\n", strings.Join(append([]string{path}, opts.Args...), " "))
+	cmd, err := process.Start(inst)
+	if err != nil {
+		fmt.Printf("\nE> Error ERR_RUN_DETACHED: %s\n", err.Error())
+		Exit(1)
+	}
+	fmt.Printf("#> Started app [%s] with id %s (pid %d), logs: %s\n", inst.Name, inst.ID, inst.PID, inst.LogFile)
+	if ready != nil {
+		exited := make(chan error, 1)
+		go func() {
+			exited <- cmd.Wait()
+		}()
+		if ready.log != nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go process.Follow(ctx, inst.LogFile, 0, ready.log)
+		}
+		waitReady(cmd, ready, opts.Ready.Timeout, exited)
+	} else {
+		cmd.Process.Release()
+	}
+	fmt.Printf("#> Follow its logs with 'run-flogo-app logs -f %s' and stop it with 'run-flogo-app stop %s'\n", inst.ID, inst.ID)
+	Exit(0)
+}
+
+// PortInUseError is returned by Start when a port of the app is not available
+type PortInUseError struct {
+	Port *flogo.TriggerPort
+	// Holder is the process holding the port, if found
+	Holder *ports.Holder
+	Err    error
+}
+
+func (e *PortInUseError) Error() string {
+	if e.Holder != nil {
+		return fmt.Sprintf("port %s is held by %s", e.Port, e.Holder)
+	}
+	return fmt.Sprintf("port %s is not available: %s", e.Port, e.Err.Error())
+}
+
+// Start starts the app at path in the background without any prompt, it
+// fails with a PortInUseError if a port of the app is not available. The
+// returned command is started, the caller has to wait for it or release it.
+func Start(path string, opts *RunOptions) (*process.Instance, *exec.Cmd, error) {
+	if err := os.Chmod(path, 0700); err != nil {
+		return nil, nil, err
+	}
+	base, env := runEnv(opts)
+	if tps, err := appPorts(path, env, opts.Ports); err == nil {
+		for _, tp := range tps {
+			if err := ports.Check(tp.Port); err != nil {
+				holder, _ := ports.FindHolder(tp.Port)
+				return nil, nil, &PortInUseError{Port: tp, Holder: holder, Err: err}
+			}
+		}
+	}
+	dir, _ := os.Getwd()
+	inst := &process.Instance{Path: path, Args: opts.Args, Env: env[len(base):], Dir: dir, Sandbox: opts.Sandbox}
+	cmd, err := process.Start(inst)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inst, cmd, nil
+}
+
+// PrintProcesses prints the apps running in the background, all include the
+// ones which have exited
+func PrintProcesses(all bool) {
+	instances := listProcesses()
+	var shown []*process.Instance
+	for _, inst := range instances {
+		if all || inst.Running() {
+			shown = append(shown, inst)
+		}
+	}
+	if len(shown) == 0 {
+		if len(instances) > 0 {
+			fmt.Printf("#> No apps running in the background, %d exited (see them with 'run-flogo-app ps -a')\n", len(instances))
+			return
+		}
+		fmt.Println("#> No apps running in the background, run one with 'run-flogo-app --detach'")
+		return
+	}
+	fmt.Printf("%-4s %-8s %-8s %-10s %s\n", "ID", "PID", "STATUS", "UPTIME", "APP")
+	for _, inst := range shown {
+		uptime := "-"
+		if inst.Running() {
+			uptime = time.Since(inst.StartedAt).Round(time.Second).String()
+		}
+		fmt.Printf("%-4s %-8d %-8s %-10s %s\n", inst.ID, inst.PID, inst.Status(), uptime, inst.Name)
+	}
+}
+
+// StopProcesses stops the apps with the ids or names and removes them from
+// the registry, all stops all of them
+func StopProcesses(queries []string, all bool) {
+	var instances []*process.Instance
+	if all {
+		instances = listProcesses()
+	}
+	for _, q := range queries {
+		instances = append(instances, findProcess(q))
+	}
+	failed := false
+	for _, inst := range instances {
+		running := inst.Running()
+		err := process.Stop(inst, config.StopTimeout*time.Second)
+		if err != nil {
+			fmt.Printf("E> Error ERR_STOP_APP: unable to stop app [%s] with id %s: %s\n", inst.Name, inst.ID, err.Error())
+			failed = true
+			continue
+		}
+		err = process.Remove(inst.ID)
+		if err != nil {
+			fmt.Printf("W> Unable to remove app [%s] with id %s from the registry: %s\n", inst.Name, inst.ID, err.Error())
+		}
+		if running {
+			fmt.Printf("#> Stopped app [%s] with id %s (pid %d)\n", inst.Name, inst.ID, inst.PID)
+		} else {
+			fmt.Printf("#> Removed app [%s] with id %s which had already exited\n", inst.Name, inst.ID)
+		}
+	}
+	if failed {
+		Exit(1)
+	}
+}
+
+// RestartProcess stops the app with the id or name and starts it again
+func RestartProcess(query string) {
+	inst := findProcess(query)
+	cmd, err := process.Restart(inst, config.StopTimeout*time.Second)
+	if err != nil {
+		fmt.Printf("E> Error ERR_RESTART_APP: unable to restart app [%s] with id %s: %s\n", inst.Name, inst.ID, err.Error())
+		Exit(1)
+	}
+	cmd.Process.Release()
+	fmt.Printf("#> Restarted app [%s] with id %s (pid %d)\n", inst.Name, inst.ID, inst.PID)
+}
+
+// ShowLogs prints the last lines of the log of the app with the id or name,
+// all the lines if negative, and keeps printing the new lines with follow
+func ShowLogs(query string, lines int, follow bool) {
+	inst := findProcess(query)
+	err := process.Logs(context.Background(), inst.LogFile, lines, follow, os.Stdout)
+	if err != nil {
+		fmt.Printf("E> Error ERR_APP_LOGS: %s\n", err.Error())
+		Exit(1)
+	}
+}
+
+func listProcesses() []*process.Instance {
+	instances, err := process.List()
+	if err != nil {
+		fmt.Printf("E> Error ERR_PROCESS_REGISTRY: %s\n", err.Error())
+		Exit(1)
+	}
+	return instances
+}
+
+func findProcess(query string) *process.Instance {
+	inst, err := process.Find(query)
+	if err != nil {
+		fmt.Printf("E> Error ERR_APP_NOT_FOUND: %s\n", err.Error())
+		Exit(1)
+	}
+	return inst
+}
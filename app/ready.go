@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+	"github.com/abhijitWakchaure/run-flogo-app/probe"
+)
+
+// ReadyAuto selects the trigger ports of the app for the TCP probe
+const ReadyAuto = "auto"
+
+// readyInterval is the interval between the checks of the probes
+const readyInterval = 250 * time.Millisecond
+
+// ReadyOptions holds the probes used to detect when the launched app is ready
+type ReadyOptions struct {
+	// HTTP is a URL or a path requested on the trigger port
+	HTTP string
+	// TCP is a host:port, a port or ReadyAuto for the trigger ports
+	TCP string
+	// Log is a regex matched against the output of the app
+	Log string
+	// Port is the trigger port, it is detected from the app if not set
+	Port    int
+	Timeout time.Duration
+}
+
+// readiness holds the probes of the app being run
+type readiness struct {
+	probes []probe.Probe
+	log    *probe.Log
+}
+
+// newReadiness returns the probes for the app with the ports tps, or the
+// error of detecting them. If no probe is selected, the ports are probed or
+// the log is watched for the default pattern if there are none.
+func newReadiness(tps []*flogo.TriggerPort, portsErr error, opts *ReadyOptions) (*readiness, error) {
+	r := new(readiness)
+	detectPorts := func() ([]int, error) {
+		if opts.Port > 0 {
+			return []int{opts.Port}, nil
+		}
+		if portsErr != nil {
+			return nil, fmt.Errorf("unable to detect the trigger port, set it with --ready-port: %s", portsErr.Error())
+		}
+		var ports []int
+		for _, tp := range tps {
+			ports = append(ports, tp.Port)
+		}
+		return ports, nil
+	}
+	if opts.HTTP != "" {
+		url := opts.HTTP
+		if !strings.Contains(url, "://") {
+			p, err := detectPorts()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(url, "/") {
+				url = "/" + url
+			}
+			url = fmt.Sprintf("http://%s%s", localAddr(p[0]), url)
+		}
+		r.probes = append(r.probes, &probe.HTTP{URL: url})
+	}
+	if opts.TCP != "" {
+		var addrs []string
+		if opts.TCP == ReadyAuto {
+			p, err := detectPorts()
+			if err != nil {
+				return nil, err
+			}
+			for _, port := range p {
+				addrs = append(addrs, localAddr(port))
+			}
+		} else if port, err := strconv.Atoi(opts.TCP); err == nil {
+			addrs = append(addrs, localAddr(port))
+		} else if _, _, err := net.SplitHostPort(opts.TCP); err == nil {
+			addrs = append(addrs, opts.TCP)
+		} else {
+			return nil, fmt.Errorf("invalid TCP probe [%s], it must be a port or host:port", opts.TCP)
+		}
+		for _, addr := range addrs {
+			r.probes = append(r.probes, &probe.TCP{Addr: addr})
+		}
+	}
+	if opts.Log != "" {
+		re, err := regexp.Compile(opts.Log)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log pattern [%s]: %s", opts.Log, err.Error())
+		}
+		r.log = &probe.Log{Pattern: re}
+		r.probes = append(r.probes, r.log)
+	}
+	if len(r.probes) == 0 {
+		if p, err := detectPorts(); err == nil {
+			for _, port := range p {
+				r.probes = append(r.probes, &probe.TCP{Addr: localAddr(port)})
+			}
+		} else {
+			r.log = &probe.Log{Pattern: regexp.MustCompile(config.DefaultReadyLogPattern)}
+			r.probes = append(r.probes, r.log)
+		}
+	}
+	return r, nil
+}
+
+func (r *readiness) String() string {
+	var s []string
+	for _, p := range r.probes {
+		s = append(s, p.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+func localAddr(port int) string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
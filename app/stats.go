@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/monitor"
+)
+
+// StatsOptions holds the options to monitor the resource usage of the app
+type StatsOptions struct {
+	Interval time.Duration
+	// File is a CSV file the samples are written to if set
+	File string
+	// MetricsURL is read for the goroutine count, the metrics of the flogo
+	// HTTP service are read if it is enabled and this is not set
+	MetricsURL string
+}
+
+// statsRun samples the app while it runs
+type statsRun struct {
+	mon    *monitor.Monitor
+	file   *os.File
+	csv    *csv.Writer
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// startStats samples the app every interval, printing a status line and the
+// summary once the program exits
+func startStats(pid int, env []string, opts *StatsOptions) *statsRun {
+	sr := &statsRun{mon: monitor.New(pid, metricsURL(env, opts.MetricsURL)), done: make(chan struct{})}
+	if opts.File != "" {
+		f, err := os.Create(opts.File)
+		if err != nil {
+			fmt.Printf("W> Unable to write the stats to [%s]: %s\n", opts.File, err.Error())
+		} else {
+			sr.file, sr.csv = f, csv.NewWriter(f)
+			sr.csv.Write(monitor.CSVHeader)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sr.cancel = cancel
+	go func() {
+		defer close(sr.done)
+		err := sr.mon.Run(ctx, opts.Interval, func(s *monitor.Sample) {
+			fmt.Printf("#> Stats: %s\n", s)
+			if sr.csv != nil {
+				sr.csv.Write(s.Record())
+				sr.csv.Flush()
+			}
+		})
+		if err == monitor.ErrNotSupported {
+			fmt.Printf("W> %s, --stats is ignored\n", err.Error())
+		}
+	}()
+	OnExit(sr.stop)
+	return sr
+}
+
+// stop stops the sampling and prints the summary
+func (sr *statsRun) stop() {
+	sr.once.Do(func() {
+		sr.cancel()
+		<-sr.done
+		if sr.file != nil {
+			sr.file.Close()
+		}
+		sum := sr.mon.Summary()
+		if sum.Samples == 0 {
+			return
+		}
+		fmt.Println()
+		printSummary(&sum)
+		if sr.file != nil {
+			fmt.Printf("#> Samples written to %s\n", sr.file.Name())
+		}
+	})
+}
+
+// forwardSignals passes the interrupt and terminate signals to the app, so
+// that the program outlives the app to print the summary
+func forwardSignals(p *os.Process) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			p.Signal(sig)
+		}
+	}()
+}
+
+func printSummary(sum *monitor.Summary) {
+	fmt.Printf("#> Resource usage over %s (%d samples):\n", sum.End.Sub(sum.Start).Round(time.Second), sum.Samples)
+	fmt.Printf("#>   CPU: %.1f%% average, %.1f%% peak\n", sum.CPUAverage(), sum.CPUPeak)
+	fmt.Printf("#>   Memory (RSS): %s at start, %s at the end (%s), %s peak\n",
+		monitor.FormatBytes(sum.RSSFirst), monitor.FormatBytes(sum.RSSLast), growth(sum.RSSLast-sum.RSSFirst, true), monitor.FormatBytes(sum.RSSPeak))
+	if sum.FDsPeak >= 0 {
+		fmt.Printf("#>   File descriptors: %d at start, %d at the end (%s), %d peak\n",
+			sum.FDsFirst, sum.FDsLast, growth(int64(sum.FDsLast-sum.FDsFirst), false), sum.FDsPeak)
+	}
+	fmt.Printf("#>   Threads: %d peak\n", sum.ThreadsPeak)
+	if sum.GoroutinesPeak >= 0 {
+		fmt.Printf("#>   Goroutines: %d at start, %d at the end (%s), %d peak\n",
+			sum.GoroutinesFirst, sum.GoroutinesLast, growth(int64(sum.GoroutinesLast-sum.GoroutinesFirst), false), sum.GoroutinesPeak)
+	}
+}
+
+func growth(n int64, bytes bool) string {
+	sign := "+"
+	if n < 0 {
+		sign, n = "-", -n
+	}
+	if bytes {
+		return sign + monitor.FormatBytes(n)
+	}
+	return fmt.Sprintf("%s%d", sign, n)
+}
+
+// metricsURL returns the metrics URL, a path is read on the flogo HTTP
+// service port set in the environment of the app
+func metricsURL(env []string, configured string) string {
+	if strings.Contains(configured, "://") {
+		return configured
+	}
+	port := ""
+	for _, e := range env {
+		if strings.HasPrefix(e, config.MetricsPortEnv+"=") {
+			port = strings.TrimPrefix(e, config.MetricsPortEnv+"=")
+		}
+	}
+	if port == "" {
+		return ""
+	}
+	path := configured
+	if path == "" {
+		path = config.DefaultMetricsPath
+	}
+	return "http://127.0.0.1:" + port + "/" + strings.TrimPrefix(path, "/")
+}
+
+// ShowStats prints the resource usage of the app run in the background with
+// the id or name, every interval with watch until interrupted
+func ShowStats(query string, watch bool, interval time.Duration, metrics string) {
+	inst := findProcess(query)
+	if !inst.Running() {
+		fmt.Printf("E> Error ERR_APP_STATS: app [%s] with id %s has exited\n", inst.Name, inst.ID)
+		Exit(1)
+	}
+	mon := monitor.New(inst.PID, metricsURL(append(os.Environ(), inst.Env...), metrics))
+	// The CPU usage is measured between two samples
+	if _, err := mon.Sample(); err != nil {
+		fmt.Printf("E> Error ERR_APP_STATS: %s\n", err.Error())
+		Exit(1)
+	}
+	fmt.Printf("#> Resource usage of app [%s] with id %s (pid %d), up %s\n", inst.Name, inst.ID, inst.PID, time.Since(inst.StartedAt).Round(time.Second))
+	if !watch {
+		time.Sleep(time.Second)
+		s, err := mon.Sample()
+		if err != nil {
+			fmt.Printf("E> Error ERR_APP_STATS: %s\n", err.Error())
+			Exit(1)
+		}
+		fmt.Println(s)
+		return
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(interval):
+	}
+	err := mon.Run(ctx, interval, func(s *monitor.Sample) {
+		fmt.Printf("%s  %s\n", s.Time.Format("15:04:05"), s)
+	})
+	if err != nil {
+		fmt.Printf("\n#> %s\n", err.Error())
+	}
+	sum := mon.Summary()
+	fmt.Println()
+	printSummary(&sum)
+}
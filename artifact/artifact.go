@@ -0,0 +1,169 @@
+// Package artifact lists and fetches the flogo app binaries from an artifact
+// repo, which is either a plain directory listing served over HTTP or a JSON index
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+	"github.com/abhijitWakchaure/run-flogo-app/semver"
+)
+
+// maxIndexSize limits the size of the downloaded index or directory listing
+const maxIndexSize = 16 << 20
+
+// Artifact is an app binary available in the artifact repo
+type Artifact struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Version string `json:"version,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+}
+
+// index is the JSON index of an artifact repo, the URLs of the apps can be
+// relative to the index. The apps can also be listed as a top level array.
+type index struct {
+	Apps []*Artifact `json:"apps"`
+}
+
+// hrefRegex matches the links of a directory listing
+var hrefRegex = regexp.MustCompile(`(?i)<a\s[^>]*href\s*=\s*["']([^"']+)["']`)
+
+// osArchSuffix matches the platform part of the binary name
+var osArchSuffix = regexp.MustCompile(`[-_.](linux|windows|darwin)_[0-9A-Za-z]+`)
+
+// isLocal returns true if the repo is a local dir set with a file:// URL
+func isLocal(repo string) bool {
+	u, err := url.Parse(repo)
+	return err == nil && u.Scheme == "file"
+}
+
+// List returns the artifacts in the repo, the highest versions first. The
+// file:// URLs are only listed from a local repo, so that a remote index can
+// not make the program read the local files.
+func List(ctx context.Context, repo string) ([]*Artifact, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, repo, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := download.Client()
+	if isLocal(repo) {
+		client = download.LocalClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status from [%s]: %s", repo, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize))
+	if err != nil {
+		return nil, err
+	}
+	// The links are relative to the final URL, after the redirects
+	base := resp.Request.URL
+	var artifacts []*Artifact
+	trimmed := bytes.TrimSpace(body)
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		artifacts, err = parseIndex(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index at [%s]: %s", repo, err.Error())
+		}
+	} else {
+		artifacts = parseListing(body)
+	}
+	var valid []*Artifact
+	for _, a := range artifacts {
+		u, err := base.Parse(a.URL)
+		if err != nil || !(u.Scheme == "http" || u.Scheme == "https" || (u.Scheme == "file" && isLocal(repo))) {
+			continue
+		}
+		a.URL = u.String()
+		if a.Name == "" {
+			a.Name, _ = url.PathUnescape(path.Base(u.Path))
+		}
+		// The name is used as the file name in the apps dir
+		if a.Name == "" || a.Name != filepath.Base(a.Name) || a.Name == ".." || a.Name == "." {
+			continue
+		}
+		if a.Version == "" {
+			if v := semver.Find(osArchSuffix.ReplaceAllString(a.Name, "")); v != nil {
+				a.Version = v.Original
+			}
+		}
+		valid = append(valid, a)
+	}
+	sortArtifacts(valid)
+	return valid, nil
+}
+
+func parseIndex(body []byte) ([]*Artifact, error) {
+	if bytes.HasPrefix(body, []byte("[")) {
+		var apps []*Artifact
+		err := json.Unmarshal(body, &apps)
+		return apps, err
+	}
+	idx := new(index)
+	err := json.Unmarshal(body, idx)
+	return idx.Apps, err
+}
+
+// parseListing returns the files linked from a directory listing, the links
+// to the sub directories, parent dir and the sort links are skipped
+func parseListing(body []byte) []*Artifact {
+	var artifacts []*Artifact
+	seen := map[string]bool{}
+	for _, m := range hrefRegex.FindAllSubmatch(body, -1) {
+		href := html.UnescapeString(string(m[1]))
+		if href == "" || strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") || strings.HasSuffix(href, "/") || seen[href] {
+			continue
+		}
+		seen[href] = true
+		artifacts = append(artifacts, &Artifact{URL: href})
+	}
+	return artifacts
+}
+
+// sortArtifacts sorts by the version, highest first, and then by the name
+func sortArtifacts(artifacts []*Artifact) {
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		vi, erri := semver.Parse(artifacts[i].Version)
+		vj, errj := semver.Parse(artifacts[j].Version)
+		if erri == nil && errj == nil {
+			if c := vi.Compare(vj); c != 0 {
+				return c > 0
+			}
+		} else if erri == nil || errj == nil {
+			return erri == nil
+		}
+		return artifacts[i].Name < artifacts[j].Name
+	})
+}
+
+// Filter returns the artifacts matching the app pattern and containing the
+// (partial) name, the name is matched case insensitive
+func Filter(artifacts []*Artifact, pattern *regexp.Regexp, name string) []*Artifact {
+	var matched []*Artifact
+	name = strings.ToLower(name)
+	for _, a := range artifacts {
+		if pattern.MatchString(a.Name) && strings.Contains(strings.ToLower(a.Name), name) {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,294 @@
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newRepo serves the files at their path, with the content type of the
+// index and listing files
+func newRepo(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".json"):
+			w.Header().Set("Content-Type", "application/json")
+		case strings.HasSuffix(r.URL.Path, "/"):
+			w.Header().Set("Content-Type", "text/html")
+		default:
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func names(artifacts []*Artifact) []string {
+	var names []string
+	for _, a := range artifacts {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// checkNames checks the names can be used as file names in the apps dir
+func checkNames(t *testing.T, artifacts []*Artifact) {
+	t.Helper()
+	for _, a := range artifacts {
+		if a.Name != filepath.Base(a.Name) || strings.Contains(a.Name, "..") || strings.ContainsAny(a.Name, `/\`) {
+			t.Errorf("unsafe name [%s] of %s", a.Name, a.URL)
+		}
+	}
+}
+
+func TestListIndex(t *testing.T) {
+	srv := newRepo(t, map[string]string{
+		"/repo/index.json": `{"apps": [
+			{"name": "orders-linux_amd64", "url": "orders/1.2.0/orders-linux_amd64", "version": "1.2.0", "sha256": "abc"},
+			{"url": "orders/1.10.0/orders-v1.10.0-linux_amd64"},
+			{"url": "https://cdn.example.com/payments-v2.0.0-linux_amd64"},
+			{"name": "../evil", "url": "evil"},
+			{"name": "bin/evil", "url": "evil"},
+			{"name": "..", "url": "evil"},
+			{"url": "..%2F..%2Fevil"}
+		]}`,
+		"/repo/list.json": `[{"url": "orders-v1.0.0-linux_amd64"}]`,
+	})
+	artifacts, err := List(context.Background(), srv.URL+"/repo/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNames(t, artifacts)
+	got := strings.Join(names(artifacts), ",")
+	want := "payments-v2.0.0-linux_amd64,orders-v1.10.0-linux_amd64,orders-linux_amd64,evil"
+	if got != want {
+		t.Errorf("artifacts are [%s], want [%s]", got, want)
+	}
+	byName := map[string]*Artifact{}
+	for _, a := range artifacts {
+		byName[a.Name] = a
+	}
+	if a := byName["orders-linux_amd64"]; a == nil || a.URL != srv.URL+"/repo/orders/1.2.0/orders-linux_amd64" || a.SHA256 != "abc" {
+		t.Errorf("relative URL of the index is not resolved: %+v", a)
+	}
+	if a := byName["orders-v1.10.0-linux_amd64"]; a == nil || a.Version != "v1.10.0" {
+		t.Errorf("version is not found in the name: %+v", a)
+	}
+	if a := byName["payments-v2.0.0-linux_amd64"]; a == nil || a.URL != "https://cdn.example.com/payments-v2.0.0-linux_amd64" {
+		t.Errorf("absolute URL of the index is changed: %+v", a)
+	}
+
+	artifacts, err = List(context.Background(), srv.URL+"/repo/list.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names(artifacts), ","); got != "orders-v1.0.0-linux_amd64" {
+		t.Errorf("artifacts of the array index are [%s]", got)
+	}
+}
+
+func TestListFileURLs(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	srv := newRepo(t, map[string]string{
+		"/repo/index.json": `{"apps": [{"name": "orders-linux_amd64", "url": "file://` + filepath.ToSlash(secret) + `"}]}`,
+	})
+	artifacts, err := List(context.Background(), srv.URL+"/repo/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 0 {
+		t.Errorf("file URL of a remote index is listed: %+v", artifacts[0])
+	}
+	a := &Artifact{Name: "orders-linux_amd64", URL: "file://" + filepath.ToSlash(secret)}
+	if _, err := Fetch(context.Background(), a, srv.URL+"/repo/index.json", t.TempDir()); err == nil {
+		t.Error("file URL is fetched from a remote repo")
+	}
+
+	// The file URLs of a local repo are listed and fetched
+	index := filepath.Join(dir, "index.json")
+	if err := os.WriteFile(index, []byte(`[{"url": "orders-v1.0.0-linux_amd64"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "orders-v1.0.0-linux_amd64"), []byte("orders"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo := "file://" + filepath.ToSlash(index)
+	artifacts, err = List(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 1 {
+		t.Fatalf("artifacts of the local repo are %v", names(artifacts))
+	}
+	dst, err := Fetch(context.Background(), artifacts[0], repo, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "orders" {
+		t.Errorf("app fetched from the local repo has %q", data)
+	}
+}
+
+func TestListInvalidIndex(t *testing.T) {
+	srv := newRepo(t, map[string]string{"/repo/index.json": `{"apps": [`})
+	if _, err := List(context.Background(), srv.URL+"/repo/index.json"); err == nil {
+		t.Error("invalid index is accepted")
+	}
+	if _, err := List(context.Background(), srv.URL+"/missing/"); err == nil {
+		t.Error("missing repo is accepted")
+	}
+}
+
+func TestListHTML(t *testing.T) {
+	srv := newRepo(t, map[string]string{
+		"/apps/": `<html><body><h1>Index of /apps</h1>
+			<a href="?C=N;O=D">Name</a> <a href="#top">Top</a>
+			<a href="../">Parent Directory</a>
+			<a href="old/">old/</a>
+			<A HREF='orders-v1.2.0-linux_amd64'>orders-v1.2.0-linux_amd64</A>
+			<a class="file" href="orders-v1.2.0-linux_amd64">again</a>
+			<a href="payments%20v1-linux_amd64">payments v1</a>
+			<a href="q?a=1&amp;b=2">query</a>
+			<a href="../../etc/passwd">passwd</a>
+			<a href="..%2F..%2Fevil">evil</a>
+		</body></html>`,
+	})
+	artifacts, err := List(context.Background(), srv.URL+"/apps/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNames(t, artifacts)
+	got := map[string]*Artifact{}
+	for _, a := range artifacts {
+		got[a.Name] = a
+	}
+	if a := got["orders-v1.2.0-linux_amd64"]; a == nil || a.URL != srv.URL+"/apps/orders-v1.2.0-linux_amd64" || a.Version != "v1.2.0" {
+		t.Errorf("link of the listing is not resolved: %+v", a)
+	}
+	if a := got["payments v1-linux_amd64"]; a == nil {
+		t.Errorf("escaped link is not unescaped: %v", names(artifacts))
+	}
+	if a := got["q"]; a == nil || a.URL != srv.URL+"/apps/q?a=1&b=2" {
+		t.Errorf("html entities of the link are not unescaped: %+v", a)
+	}
+	for _, skipped := range []string{"", "apps", "old", "Name", "top"} {
+		if _, ok := got[skipped]; ok {
+			t.Errorf("link [%s] of the listing is not skipped", skipped)
+		}
+	}
+	if len(artifacts) != len(got) {
+		t.Errorf("duplicate links in %v", names(artifacts))
+	}
+}
+
+func TestFetch(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	content := "#!/bin/sh\necho orders\n"
+	sum := sha256.Sum256([]byte(content))
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(content))
+	}))
+	defer srv.Close()
+	appsDir := t.TempDir()
+	a := &Artifact{Name: "orders-linux_amd64", URL: srv.URL + "/orders-linux_amd64", SHA256: hex.EncodeToString(sum[:])}
+
+	if IsFetched(a, appsDir) {
+		t.Fatal("artifact is fetched before being downloaded")
+	}
+	dst, err := Fetch(context.Background(), a, srv.URL, appsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != filepath.Join(appsDir, a.Name) {
+		t.Errorf("app is fetched to %s", dst)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil || string(data) != content {
+		t.Fatalf("fetched app has %q, %v", data, err)
+	}
+	if fi, _ := os.Stat(dst); fi.Mode().Perm()&0100 == 0 {
+		t.Errorf("fetched app is not executable: %s", fi.Mode())
+	}
+	// The cached app is used unless it is fetched with --force
+	if !IsFetched(a, appsDir) {
+		t.Error("fetched artifact is not cached")
+	}
+	if other := (&Artifact{Name: a.Name, URL: srv.URL + "/other"}); IsFetched(other, appsDir) {
+		t.Error("artifact of another URL is cached")
+	}
+	if other := (&Artifact{Name: a.Name, URL: a.URL, SHA256: strings.Repeat("0", 64)}); IsFetched(other, appsDir) {
+		t.Error("artifact of another checksum is cached")
+	}
+	if f := ReadFetched()[dst]; f == nil || f.SHA256 != a.SHA256 || f.Repo != srv.URL {
+		t.Errorf("metadata of the fetched app is %+v", f)
+	}
+
+	// A changed file is fetched again, like with --force
+	if err := os.WriteFile(dst, []byte("changed"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if IsFetched(a, appsDir) {
+		t.Error("changed app is cached")
+	}
+	if _, err := Fetch(context.Background(), a, srv.URL, appsDir); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != content || requests != 2 {
+		t.Errorf("app is not fetched again, it has %q after %d requests", data, requests)
+	}
+}
+
+func TestFetchChecksumMismatch(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered"))
+	}))
+	defer srv.Close()
+	appsDir := t.TempDir()
+	a := &Artifact{Name: "orders-linux_amd64", URL: srv.URL + "/orders-linux_amd64", SHA256: strings.Repeat("a", 64)}
+	_, err := Fetch(context.Background(), a, srv.URL, appsDir)
+	if err == nil || !strings.Contains(err.Error(), "SHA-256") {
+		t.Fatalf("checksum mismatch is not reported: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(appsDir, a.Name)); !os.IsNotExist(err) {
+		t.Error("app with a checksum mismatch is kept")
+	}
+	if IsFetched(a, appsDir) {
+		t.Error("app with a checksum mismatch is cached")
+	}
+
+	// An existing app with the same name is kept
+	dst := filepath.Join(appsDir, a.Name)
+	if err := os.WriteFile(dst, []byte("good"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Fetch(context.Background(), a, srv.URL, appsDir); err == nil {
+		t.Fatal("checksum mismatch is not reported")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "good" {
+		t.Errorf("existing app is replaced with %q", data)
+	}
+	if _, err := os.Stat(dst + ".part"); !os.IsNotExist(err) {
+		t.Error("download with a checksum mismatch is kept")
+	}
+}
@@ -0,0 +1,91 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+)
+
+// Fetched is the metadata of an app fetched from the artifact repo
+type Fetched struct {
+	URL       string    `json:"url"`
+	Repo      string    `json:"repo"`
+	Version   string    `json:"version,omitempty"`
+	SHA256    string    `json:"sha256"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// fetchedFilePath returns the path of the file with the metadata of the
+// fetched apps, keyed by the path of the app
+func fetchedFilePath() string {
+	return filepath.Join(config.StateDir(), config.FetchedFileName)
+}
+
+// ReadFetched returns the metadata of the fetched apps, keyed by their path
+func ReadFetched() map[string]*Fetched {
+	fetched := map[string]*Fetched{}
+	m, err := config.ReadFile(fetchedFilePath())
+	if err != nil {
+		return fetched
+	}
+	b, _ := json.Marshal(m)
+	json.Unmarshal(b, &fetched)
+	return fetched
+}
+
+// IsFetched returns true if the artifact is already fetched into the apps
+// dir and the file has not changed since
+func IsFetched(a *Artifact, appsDir string) bool {
+	dst := filepath.Join(appsDir, a.Name)
+	f, ok := ReadFetched()[dst]
+	if !ok || f.URL != a.URL {
+		return false
+	}
+	if a.SHA256 != "" && !strings.EqualFold(a.SHA256, f.SHA256) {
+		return false
+	}
+	sum, err := download.SHA256File(dst)
+	return err == nil && sum == f.SHA256
+}
+
+// Fetch downloads the artifact into the apps dir, verifies its checksum if
+// the repo has it and makes it executable. It returns the path of the app.
+// The download replaces an existing app only once its checksum is verified. A
+// file:// URL is only fetched from a local repo.
+func Fetch(ctx context.Context, a *Artifact, repo, appsDir string) (string, error) {
+	dst := filepath.Join(appsDir, a.Name)
+	err := download.File(ctx, a.URL, dst, &download.Options{SHA256: a.SHA256, Local: isLocal(repo)})
+	var cerr *download.ChecksumError
+	if errors.As(err, &cerr) {
+		return "", fmt.Errorf("SHA-256 of [%s] is %s but the artifact repo has %s, the download is deleted", a.Name, cerr.Actual, a.SHA256)
+	}
+	if err != nil {
+		return "", err
+	}
+	sum, err := download.SHA256File(dst)
+	if err != nil {
+		return "", err
+	}
+	err = os.Chmod(dst, 0755)
+	if err != nil {
+		return "", err
+	}
+	// The fetched app becomes the latest one for the mtime strategy
+	now := time.Now()
+	os.Chtimes(dst, now, now)
+	err = config.Update(fetchedFilePath(), func(m map[string]interface{}) {
+		m[dst] = &Fetched{URL: a.URL, Repo: repo, Version: a.Version, SHA256: sum, FetchedAt: now.UTC()}
+	})
+	if err != nil {
+		fmt.Printf("W> Unable to save the metadata of the fetched app: %s\n", err.Error())
+	}
+	return dst, nil
+}
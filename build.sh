@@ -4,7 +4,16 @@ GEN_DOCS="false"
 
 APP_NAME="run-flogo-app"
 APP_VERSION=`cat VERSION`
-LDFLAGS="-s -w -X 'github.com/abhijitWakchaure/run-flogo-app/config.VERSION=${APP_VERSION}'"
+# The public key verifying the updates is taken from RELEASE_PUBLIC_KEY, or
+# derived from the ed25519 private key in SIGNING_KEY
+if [ -z "${RELEASE_PUBLIC_KEY}" ] && [ -n "${SIGNING_KEY}" ]; then
+    RELEASE_PUBLIC_KEY=$(openssl pkey -in "${SIGNING_KEY}" -pubout -outform DER | tail -c 32 | base64)
+fi
+if [ -z "${RELEASE_PUBLIC_KEY}" ]; then
+    echo "### RELEASE_PUBLIC_KEY or SIGNING_KEY must be set, the binaries need the public key to verify the signature of the updates"
+    exit 1
+fi
+LDFLAGS="-s -w -X 'github.com/abhijitWakchaure/run-flogo-app/config.VERSION=${APP_VERSION}' -X 'github.com/abhijitWakchaure/run-flogo-app/config.ReleasePublicKey=${RELEASE_PUBLIC_KEY}'"
 
 echo "Building binaries for ${APP_NAME}-${APP_VERSION}"
 
@@ -28,3 +37,13 @@ echo "### Building for platform: windows/arm64"
 GOOS=windows GOARCH=arm64 go build ${DOC_TAG} -ldflags "${LDFLAGS}" -o dist/${APP_NAME}-windows_arm64.exe
 echo "### Building for platform: darwin/arm"
 GOOS=darwin GOARCH=arm64 go build ${DOC_TAG} -ldflags "${LDFLAGS}" -o dist/${APP_NAME}-darwin_arm64
+
+echo "### Generating checksums"
+# The version in the signed manifest stops an older release being served as a newer one
+(cd dist && echo "# version: ${APP_VERSION}" > checksums.txt && sha256sum ${APP_NAME}-* >> checksums.txt)
+if [ -n "${SIGNING_KEY}" ]; then
+    echo "### Signing checksums with key: ${SIGNING_KEY}"
+    openssl pkeyutl -sign -inkey "${SIGNING_KEY}" -rawin -in dist/checksums.txt -out dist/checksums.txt.sig
+else
+    echo "### SIGNING_KEY is not set, upload dist/checksums.txt with its signature dist/checksums.txt.sig or the update command will refuse to install the release"
+fi
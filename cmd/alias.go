@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var validAliasName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// aliasCmd represents the alias command
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage the saved launch recipes which can be run as run-flogo-app @<alias>",
+}
+
+// aliasAddCmd represents the alias add command
+var aliasAddCmd = &cobra.Command{
+	Use:   "add <alias> <recipe>",
+	Short: "Add or replace an alias, e.g. alias add orders \"-n order-service -d -- --port 9999\"",
+	Example: `  run-flogo-app alias add orders "-n order-service -d -e HTTP_PORT=9999 -- --verbose"
+  run-flogo-app alias add orders-qa "-n order-service --env-profile qa --props qa.json -d -- --port 9999"
+  run-flogo-app alias add orders-bg "-n order-service --detach --ready-http=/health --ready-timeout 1m"`,
+	// The recipe contains flags of the root command which should not be parsed here
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			cmd.Help()
+			return
+		}
+		if len(args) < 2 {
+			fmt.Println("E> Error ERR_INVALID_ALIAS: please provide the alias name and the recipe")
+			os.Exit(1)
+		}
+		aliasName := strings.ToLower(args[0])
+		if !validAliasName.MatchString(aliasName) {
+			fmt.Printf("E> Error ERR_INVALID_ALIAS: alias name [%s] can only contain letters, numbers, '.', '_' and '-'\n", args[0])
+			os.Exit(1)
+		}
+		fields := args[1:]
+		if len(args) == 2 {
+			var err error
+			fields, err = splitRecipe(args[1])
+			if err != nil {
+				fmt.Printf("E> Error ERR_INVALID_ALIAS: %s\n", err.Error())
+				os.Exit(1)
+			}
+		}
+		alias := parseAlias(fields)
+		app.UpdateAliases(func(aliases map[string]interface{}) {
+			aliases[aliasName] = alias
+		})
+		fmt.Printf("#> Saved alias @%s: %s\n", aliasName, alias.Recipe())
+	},
+}
+
+// aliasListCmd represents the alias list command
+var aliasListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List all the aliases",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(a.Aliases) == 0 {
+			fmt.Println("#> No aliases found, add one with 'run-flogo-app alias add'")
+			return
+		}
+		var names []string
+		for name := range a.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println("#> Aliases:")
+		for _, name := range names {
+			fmt.Printf("@%-20s %s\n", name, a.Aliases[name].Recipe())
+		}
+	},
+}
+
+// aliasRemoveCmd represents the alias remove command
+var aliasRemoveCmd = &cobra.Command{
+	Use:     "remove <alias>",
+	Aliases: []string{"rm"},
+	Short:   "Remove an alias",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		aliasName := strings.ToLower(strings.TrimPrefix(args[0], "@"))
+		found := false
+		app.UpdateAliases(func(aliases map[string]interface{}) {
+			for name := range aliases {
+				if strings.EqualFold(name, aliasName) {
+					delete(aliases, name)
+					found = true
+				}
+			}
+		})
+		if !found {
+			fmt.Printf("E> Error ERR_ALIAS_NOT_FOUND: no alias found with name [%s] in config file, aliases from project config files must be removed from those files\n", aliasName)
+			os.Exit(1)
+		}
+		fmt.Printf("#> Removed alias @%s\n", aliasName)
+	},
+}
+
+func init() {
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+	rootCmd.AddCommand(aliasCmd)
+}
+
+// parseAlias parses the recipe fields using the same flags as the root command
+func parseAlias(fields []string) *config.Alias {
+	fs := pflag.NewFlagSet("alias", pflag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	addRunFlags(fs)
+	err := fs.Parse(fields)
+	if err != nil {
+		fmt.Printf("E> Error ERR_INVALID_ALIAS: %s\n", err.Error())
+		os.Exit(1)
+	}
+	rf := readRunFlags(fs)
+	rf.validate()
+	// An alias can come from a project config, which can not set the program run
+	if fs.Changed("container-runtime") {
+		fmt.Println("E> Error ERR_INVALID_ALIAS: --container-runtime can not be saved in an alias, set it with 'run-flogo-app config set containerRuntime <runtime>'")
+		os.Exit(1)
+	}
+	alias := &config.Alias{
+		Name:       rf.name,
+		List:       rf.list,
+		SortBy:     rf.sortBy,
+		Env:        rf.env,
+		Ports:      rf.ports,
+		Detach:     rf.detach,
+		Args:       fs.Args(),
+		EnvProfile: rf.envProfile,
+	}
+	// The alias can be run from any dir
+	if rf.props != "" {
+		props, err := filepath.Abs(rf.props)
+		if err != nil {
+			fmt.Printf("E> Error ERR_INVALID_ALIAS: %s\n", err.Error())
+			os.Exit(1)
+		}
+		alias.Props = props
+	}
+	if rf.debug || rf.trace {
+		alias.LogLevel = rf.logLevel()
+	}
+	alias.Ready, _ = fs.GetBool("ready")
+	alias.ReadyHTTP = changedFlag(fs, "ready-http")
+	alias.ReadyTCP = changedFlag(fs, "ready-tcp")
+	alias.ReadyLog = changedFlag(fs, "ready-log")
+	alias.ReadyPort, _ = fs.GetInt("ready-port")
+	alias.ReadyTimeout = changedFlag(fs, "ready-timeout")
+	alias.Stats, _ = fs.GetBool("stats")
+	alias.StatsInterval = changedFlag(fs, "stats-interval")
+	alias.StatsMetrics = changedFlag(fs, "stats-metrics")
+	// The alias can be run from any dir
+	if file := changedFlag(fs, "stats-file"); file != "" {
+		file, err := filepath.Abs(file)
+		if err != nil {
+			fmt.Printf("E> Error ERR_INVALID_ALIAS: %s\n", err.Error())
+			os.Exit(1)
+		}
+		alias.StatsFile = file
+	}
+	alias.LimitMemory = changedFlag(fs, "limit-memory")
+	alias.LimitCPUTime = changedFlag(fs, "limit-cpu-time")
+	alias.LimitFiles, _ = strconv.Atoi(changedFlag(fs, "limit-files"))
+	alias.LimitCPUs = changedFlag(fs, "limit-cpus")
+	alias.LimitPIDs, _ = fs.GetInt("limit-pids")
+	alias.Cgroup, _ = fs.GetBool("cgroup")
+	alias.Isolate, _ = fs.GetStringSlice("isolate")
+	alias.CleanEnv, _ = fs.GetBool("clean-env")
+	alias.Container = changedFlag(fs, "container")
+	if workdir := changedFlag(fs, "workdir"); workdir != "" {
+		workdir, err := filepath.Abs(workdir)
+		if err != nil {
+			fmt.Printf("E> Error ERR_INVALID_ALIAS: %s\n", err.Error())
+			os.Exit(1)
+		}
+		alias.Workdir = workdir
+	}
+	return alias
+}
+
+// changedFlag returns the value of the flag if it is set in the recipe
+func changedFlag(fs *pflag.FlagSet, name string) string {
+	if !fs.Changed(name) {
+		return ""
+	}
+	return fs.Lookup(name).Value.String()
+}
+
+// splitRecipe splits the recipe into fields like a shell would, honouring
+// single quotes, double quotes and backslash escapes
+func splitRecipe(recipe string) ([]string, error) {
+	var fields []string
+	var cur strings.Builder
+	var quote rune
+	inField, escaped := false, false
+	for _, r := range recipe {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inField = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+		default:
+			cur.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in recipe [%s]", recipe)
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
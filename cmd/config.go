@@ -1,7 +1,18 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
 	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/software"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +25,216 @@ var configCmd = &cobra.Command{
 	},
 }
 
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a key from the config file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		spec := lookupConfigKey(args[0])
+		value := fileConfig().Get(spec.Name)
+		if s, ok := value.(string); ok {
+			fmt.Println(s)
+			return
+		}
+		b, _ := json.MarshalIndent(value, "", "  ")
+		fmt.Println(string(b))
+	},
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Set the value of a key in the config file",
+	Long: "Set the value of a key in the config file. The keys which can be set are: " + strings.Join(config.SettableKeys(), ", ") +
+		". Provide multiple values for the list keys like env.",
+	Example: `  run-flogo-app config set appsDir ~/flogo-apps
+  run-flogo-app config set env FLOGO_APP_PROPS_ENV=auto HTTP_PORT=9999`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		spec := lookupConfigKey(args[0])
+		if !spec.Settable {
+			fmt.Printf("E> Error ERR_CONFIG_NOT_SETTABLE: key [%s] can not be set, the keys which can be set are: %s\n", spec.Name, strings.Join(config.SettableKeys(), ", "))
+			os.Exit(1)
+		}
+		values := args[1:]
+		var value interface{}
+		switch spec.Kind {
+		case config.KindList:
+			value = values
+		case config.KindBool:
+			b, err := strconv.ParseBool(singleValue(spec, values))
+			if err != nil {
+				fmt.Printf("E> Error ERR_CONFIG_TYPE: key [%s] must be a bool\n", spec.Name)
+				os.Exit(1)
+			}
+			value = b
+		case config.KindNumber:
+			i, err := strconv.Atoi(singleValue(spec, values))
+			if err != nil {
+				fmt.Printf("E> Error ERR_CONFIG_TYPE: key [%s] must be a whole number\n", spec.Name)
+				os.Exit(1)
+			}
+			value = i
+		default:
+			s := singleValue(spec, values)
+			if spec.Name == "appsDir" {
+				abs, err := filepath.Abs(s)
+				if err == nil {
+					s = abs
+				}
+			}
+			value = s
+		}
+		if spec.Check != nil {
+			if err := spec.Check(value); err != nil {
+				fmt.Printf("E> Error ERR_INVALID_CONFIG: %s\n", err.Error())
+				os.Exit(1)
+			}
+		}
+		app.WriteConfigValue(spec.Name, value)
+		fmt.Printf("#> Updated [%s] in config file\n", spec.Name)
+	},
+}
+
+// configUnsetCmd represents the config unset command
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a key from the config file so that its default value is used",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		spec := lookupConfigKey(args[0])
+		if !spec.Settable {
+			fmt.Printf("E> Error ERR_CONFIG_NOT_SETTABLE: key [%s] can not be unset, the keys which can be unset are: %s\n", spec.Name, strings.Join(config.SettableKeys(), ", "))
+			os.Exit(1)
+		}
+		app.UnsetConfigValue(spec.Name)
+		fmt.Printf("#> Removed [%s] from config file\n", spec.Name)
+	},
+}
+
+// configEditCmd represents the config edit command
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $EDITOR and validate it after editing",
+	Run: func(cmd *cobra.Command, args []string) {
+		path := config.SettingsFilePath()
+		for {
+			editor := editorCommand()
+			c := exec.Command(editor[0], append(editor[1:], path)...)
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+			err := c.Run()
+			if err != nil {
+				fmt.Printf("E> Error ERR_RUN_EDITOR: %s\n", err.Error())
+				os.Exit(1)
+			}
+			problems := config.ValidateFile(path)
+			printProblems(path, problems)
+			if !config.HasErrors(problems) {
+				return
+			}
+			fmt.Printf("\n#> Do you want to edit the config file again? [y/n]: ")
+			if !software.HandleYNInput() {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate the config file and the project config files",
+	Long:  "Validate the syntax, the keys and the values of the config file and the project config files used from the current directory, or the given file",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var paths []string
+		if len(args) == 1 {
+			paths = args
+		} else {
+			paths = append(paths, config.SettingsFilePath())
+			if wd, err := os.Getwd(); err == nil {
+				paths = append(paths, config.FindProjectConfigs(wd, config.SettingsFilePath(), config.LegacySettingsFilePath())...)
+			}
+		}
+		valid := true
+		for _, path := range paths {
+			problems := config.ValidateFile(path)
+			if config.IsProjectConfig(path) && !sameConfigFile(path) {
+				problems = config.ValidateProjectFile(path)
+			}
+			printProblems(path, problems)
+			if config.HasErrors(problems) {
+				valid = false
+			}
+		}
+		if !valid {
+			os.Exit(1)
+		}
+	},
+}
+
+// sameConfigFile returns true if path is the config file or the legacy one
+func sameConfigFile(path string) bool {
+	abs, _ := filepath.Abs(path)
+	settings, _ := filepath.Abs(config.SettingsFilePath())
+	return abs == settings || abs == config.LegacySettingsFilePath()
+}
+
 func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
 	rootCmd.AddCommand(configCmd)
 }
+
+func lookupConfigKey(key string) *config.KeySpec {
+	spec := config.LookupKey(key)
+	if spec == nil {
+		var keys []string
+		for _, k := range config.Schema {
+			keys = append(keys, k.Name)
+		}
+		fmt.Printf("E> Error ERR_CONFIG_UNKNOWN_KEY: unknown key [%s], valid keys are: %s\n", key, strings.Join(keys, ", "))
+		os.Exit(1)
+	}
+	return spec
+}
+
+func singleValue(spec *config.KeySpec, values []string) string {
+	if len(values) != 1 {
+		fmt.Printf("E> Error ERR_CONFIG_TYPE: key [%s] takes a single value\n", spec.Name)
+		os.Exit(1)
+	}
+	return values[0]
+}
+
+// editorCommand returns the editor from $VISUAL or $EDITOR along with its args
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.Fields(os.Getenv(env)); len(editor) > 0 {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+func printProblems(path string, problems []*config.Problem) {
+	if len(problems) == 0 {
+		fmt.Printf("#> Config file [%s] is valid\n", path)
+		return
+	}
+	if config.HasErrors(problems) {
+		fmt.Printf("E> Config file [%s] is invalid:\n", path)
+	} else {
+		fmt.Printf("#> Config file [%s] is valid with warnings:\n", path)
+	}
+	for _, p := range problems {
+		fmt.Printf("   %s\n", p)
+	}
+}
@@ -10,7 +10,7 @@ var deleteCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete all the flogo apps in apps dir",
 	Run: func(cmd *cobra.Command, args []string) {
-		files.DeleteApps(a.AppsDir, a.AppPattern)
+		files.DeleteApps(a.AppsDir, a.AppPattern, a.SortBy)
 	},
 }
 
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/deploy"
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export <" + strings.Join(deploy.Formats, "|") + "> [name|@alias] [flags] [-- app args]",
+	Short: "Generate a Dockerfile, kubernetes manifests or a docker compose service for an app",
+	Long: "Generate the deployment files of the app with the (partial) name, or of the latest app, from its trigger ports, its app properties " +
+		"and the variables it is run with: the env of the config, of the alias and -e. The files are written to the output dir: " +
+		"a Dockerfile along with a copy of the app for docker, k8s.yaml with a Deployment, a Service and a ConfigMap for k8s, " +
+		"and compose.yaml for compose.",
+	Example: `  run-flogo-app export docker order-service -o build
+  run-flogo-app export k8s @orders --image registry.example.com/orders:1.2.0
+  run-flogo-app export compose -e FLOGO_APP_PROPS_ENV=auto -- --verbose`,
+	Args:      cobra.MinimumNArgs(1),
+	ValidArgs: deploy.Formats,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := args[0]
+		if deploy.FileName(format) == "" {
+			fmt.Printf("E> Error ERR_INVALID_EXPORT: unknown format [%s], valid formats are: %s\n", format, strings.Join(deploy.Formats, ", "))
+			os.Exit(1)
+		}
+		name, profile := readProfile(cmd, args, true)
+		opts := &app.ExportOptions{Format: format, Profile: *profile}
+		opts.Output, _ = cmd.Flags().GetString("output")
+		opts.Image, _ = cmd.Flags().GetString("image")
+		opts.BaseImage, _ = cmd.Flags().GetString("base-image")
+		if opts.BaseImage == "" {
+			opts.BaseImage = a.ContainerImage
+		}
+		if opts.BaseImage == "" {
+			opts.BaseImage = config.DefaultContainerImage
+		}
+		a.Export(name, opts)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringP("output", "o", ".", "Dir the files are written to")
+	exportCmd.Flags().String("image", "", "Image of the app in the manifests (default <app name>:<app version>)")
+	exportCmd.Flags().String("base-image", "", "Image the Dockerfile builds on (default image from config or "+config.DefaultContainerImage+")")
+	addProfileFlags(exportCmd.Flags())
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// fetchCmd represents the fetch command
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [name]",
+	Short: "Fetch a flogo app from the artifact repo into the apps dir",
+	Long: "Fetch the flogo app with given (partial) name from the artifact repo into the apps dir, so that it can be run with -n. " +
+		"The artifact repo is set by artifactRepo in config file, it can be a directory listing served over HTTP or a JSON index.",
+	Example: `  run-flogo-app fetch --list
+  run-flogo-app fetch order-service
+  run-flogo-app -n order-service`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		list, _ := cmd.Flags().GetBool("list")
+		force, _ := cmd.Flags().GetBool("force")
+		if list {
+			a.ListArtifacts(name)
+			return
+		}
+		a.Fetch(name, force)
+	},
+}
+
+func init() {
+	fetchCmd.Flags().BoolP("list", "l", false, "List the apps in the artifact repo instead of fetching")
+	fetchCmd.Flags().Bool("force", false, "Fetch the app again even if it is already fetched")
+	rootCmd.AddCommand(fetchCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/oci"
+	"github.com/spf13/cobra"
+)
+
+// imageCmd represents the image command
+var imageCmd = &cobra.Command{
+	Use:   "image [name|@alias] [flags] [-- app args]",
+	Short: "Build an OCI image of an app without docker",
+	Long: "Build the OCI image of the app with the (partial) name, or of the latest app, without a container daemon. " +
+		"The image has the base image, and a layer with the app in " + oci.AppDir + ", the CA certificates of the system and the added files. " +
+		"Its config has the variables the app is run with (the env of the config, of the alias and -e), its trigger ports and its args. " +
+		"The image is written as a tarball, which can be loaded with docker load or podman load, or as an OCI image layout dir, which can be pushed with skopeo. " +
+		"The base image is scratch, an OCI image layout dir or tarball, or an image pulled from a registry with the credentials of docker login.",
+	Example: `  run-flogo-app image order-service
+  run-flogo-app image @orders --base alpine:3 --image registry.example.com/orders:1.2.0 -o orders.tar
+  run-flogo-app image order-service --add config/app.json:config.json -o build/oci`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, profile := readProfile(cmd, args, false)
+		opts := &app.ImageOptions{Profile: *profile}
+		opts.Output, _ = cmd.Flags().GetString("output")
+		opts.Base, _ = cmd.Flags().GetString("base")
+		opts.Image, _ = cmd.Flags().GetString("image")
+		opts.User, _ = cmd.Flags().GetString("user")
+		if entrypoint, _ := cmd.Flags().GetString("entrypoint"); entrypoint != "" {
+			fields, err := splitRecipe(entrypoint)
+			if err != nil {
+				fmt.Printf("E> Error ERR_INVALID_IMAGE: invalid --entrypoint: %s\n", err.Error())
+				os.Exit(1)
+			}
+			opts.Entrypoint = fields
+		}
+		if noCerts, _ := cmd.Flags().GetBool("no-ca-certs"); noCerts {
+			opts.CACerts = []string{}
+		}
+		if certs, _ := cmd.Flags().GetStringArray("ca-certs"); len(certs) > 0 {
+			opts.CACerts = certs
+		}
+		adds, _ := cmd.Flags().GetStringArray("add")
+		for _, add := range adds {
+			f := oci.File{Src: add}
+			// The separator is the last colon, which is not the one of a
+			// windows drive
+			if i := strings.LastIndex(add, ":"); i > 1 {
+				f.Src, f.Dst = add[:i], add[i+1:]
+			}
+			if _, err := os.Stat(f.Src); err != nil {
+				fmt.Printf("E> Error ERR_INVALID_IMAGE: invalid --add: %s\n", err.Error())
+				os.Exit(1)
+			}
+			opts.Files = append(opts.Files, f)
+		}
+		a.BuildImage(name, opts)
+	},
+}
+
+func init() {
+	imageCmd.Flags().StringP("output", "o", "", "Tarball of the image if it ends with .tar, or else dir of the OCI image layout (default <app name>.tar)")
+	imageCmd.Flags().String("base", oci.Scratch, "Base image: scratch, the path of an OCI image layout dir or tarball, or an image of a registry, e.g. alpine:3")
+	imageCmd.Flags().String("image", "", "Name and tag of the image (default <app name>:<app version>)")
+	imageCmd.Flags().String("entrypoint", "", "Entrypoint of the image, e.g. \"/app/order-service --verbose\" (default "+oci.AppDir+"/<app file>)")
+	imageCmd.Flags().String("user", "65534:65534", "User the app is run as")
+	imageCmd.Flags().StringArray("ca-certs", nil, "PEM file of the CA certificates added to the image (default the CAs of the system and the caBundle of the config)")
+	imageCmd.Flags().Bool("no-ca-certs", false, "Do not add the CA certificates to the image")
+	imageCmd.Flags().StringArray("add", nil, "Add a file or dir to the image as src[:dst], dst is relative to "+oci.AppDir)
+	addProfileFlags(imageCmd.Flags())
+	rootCmd.AddCommand(imageCmd)
+}
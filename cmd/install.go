@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/abhijitWakchaure/run-flogo-app/software"
 	"github.com/spf13/cobra"
 )
@@ -9,11 +13,37 @@ import (
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install the program",
+	Long: "Install the program system wide, or for the current user with --user which does not need sudo. " +
+		"The updates and rollbacks are installed where the program was last installed.",
+	Example: `  run-flogo-app install
+  run-flogo-app install --user
+  run-flogo-app install --prefix ~/tools`,
 	Run: func(cmd *cobra.Command, args []string) {
-		software.Install("")
+		user, _ := cmd.Flags().GetBool("user")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		var dir string
+		switch {
+		case user && prefix != "":
+			fmt.Println("E> Error ERR_INSTALL_FLAGS: --user and --prefix can not be used together")
+			os.Exit(1)
+		case user:
+			dir = software.UserInstallDir()
+		case prefix != "":
+			dir = filepath.Join(prefix, "bin")
+		default:
+			var err error
+			dir, err = software.DefaultInstallDir()
+			if err != nil {
+				fmt.Printf("E> Error ERR_INSTALL: %s\n", err.Error())
+				os.Exit(1)
+			}
+		}
+		software.Install("", dir, a.KeepVersions)
 	},
 }
 
 func init() {
+	installCmd.Flags().Bool("user", false, "Install for the current user into ~/.local/bin, without sudo")
+	installCmd.Flags().String("prefix", "", "Install into the bin dir of the given prefix, e.g. --prefix /opt installs into /opt/bin")
 	rootCmd.AddCommand(installCmd)
 }
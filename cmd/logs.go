@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/spf13/cobra"
+)
+
+// logsCmd represents the logs command
+var logsCmd = &cobra.Command{
+	Use:   "logs <id|name>",
+	Short: "Show the logs of an app running in the background",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		follow, _ := cmd.Flags().GetBool("follow")
+		lines, _ := cmd.Flags().GetInt("lines")
+		app.ShowLogs(args[0], lines, follow)
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep printing the new lines")
+	logsCmd.Flags().IntP("lines", "n", -1, "Number of lines to show from the end, all of them by default")
+	rootCmd.AddCommand(logsCmd)
+}
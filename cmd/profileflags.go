@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// addProfileFlags adds the flags of the settings the app is run with, for the
+// commands packaging the app
+func addProfileFlags(fs *pflag.FlagSet) {
+	fs.StringArrayP("env", "e", nil, "Set environment variable for the app in KEY=VALUE format")
+	addEnvFlags(fs)
+	fs.IntSlice("port", nil, "Port the app listens on (default the trigger ports of the app)")
+	fs.BoolP("debug", "d", false, "Enable debug logs")
+	fs.BoolP("trace", "t", false, "Enable trace logs")
+}
+
+// readProfile returns the app name and the profile from the flags and args,
+// with the settings of the alias if the name is an @alias. The first arg is
+// skipped if skipFirst is set, e.g. the export format. The config env and the
+// env profile are added before the other variables.
+func readProfile(cmd *cobra.Command, args []string, skipFirst bool) (string, *app.Profile) {
+	fs := cmd.Flags()
+	p := &app.Profile{LogLevel: config.LogLevelInfo}
+	p.Env, _ = fs.GetStringArray("env")
+	envProfile, _ := fs.GetString("env-profile")
+	props, _ := fs.GetString("props")
+	p.Ports, _ = fs.GetIntSlice("port")
+	if debug, _ := fs.GetBool("debug"); debug {
+		p.LogLevel = config.LogLevelDebug
+	}
+	if trace, _ := fs.GetBool("trace"); trace {
+		p.LogLevel = config.LogLevelTrace
+	}
+	// The args after -- are the args of the app
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		p.Args = args[dash:]
+		args = args[:dash]
+	}
+	if skipFirst && len(args) > 0 {
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		fmt.Printf("E> Error ERR_INVALID_ARGS: unexpected args %v, pass the args of the app after --\n", args[1:])
+		os.Exit(1)
+	}
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	if strings.HasPrefix(name, "@") {
+		aliasName := strings.ToLower(strings.TrimPrefix(name, "@"))
+		alias, ok := a.Aliases[aliasName]
+		if !ok {
+			fmt.Printf("E> Error ERR_ALIAS_NOT_FOUND: no alias found with name [%s], use 'run-flogo-app alias list' to see all aliases\n", aliasName)
+			os.Exit(1)
+		}
+		name = alias.Name
+		if alias.SortBy != "" {
+			a.SortBy = alias.SortBy
+		}
+		if !fs.Changed("debug") && !fs.Changed("trace") && alias.LogLevel != "" {
+			p.LogLevel = alias.LogLevel
+		}
+		p.Env = append(append([]string{}, alias.Env...), p.Env...)
+		if !fs.Changed("env-profile") {
+			envProfile = alias.EnvProfile
+		}
+		if !fs.Changed("props") {
+			props = alias.Props
+		}
+		if !fs.Changed("port") {
+			p.Ports = alias.Ports
+		}
+		p.Args = append(append([]string{}, alias.Args...), p.Args...)
+	}
+	for _, port := range p.Ports {
+		if port <= 0 || port > 65535 {
+			fmt.Printf("E> Error ERR_INVALID_PORT: port [%d] must be between 1 and 65535\n", port)
+			os.Exit(1)
+		}
+	}
+	p.Env = appEnv(envProfile, props, p.Env)
+	return name, p
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/spf13/cobra"
+)
+
+// psCmd represents the ps command
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List the apps running in the background",
+	Long:  "List the apps started with --detach which are running, use -a to also list the ones which have exited.",
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		app.PrintProcesses(all)
+	},
+}
+
+func init() {
+	psCmd.Flags().BoolP("all", "a", false, "Also list the apps which have exited")
+	rootCmd.AddCommand(psCmd)
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/spf13/cobra"
+)
+
+// restartCmd represents the restart command
+var restartCmd = &cobra.Command{
+	Use:   "restart <id|name>",
+	Short: "Restart an app running in the background",
+	Long:  "Stop an app started with --detach and start it again with the same args and environment, its output is appended to the same log file.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app.RestartProcess(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restartCmd)
+}
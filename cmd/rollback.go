@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/abhijitWakchaure/run-flogo-app/software"
+	"github.com/spf13/cobra"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [version]",
+	Short: "Restore a previously installed version of the program",
+	Long: "Restore a previously installed version of the program. Without a version the most recently replaced one is restored. " +
+		"The version being replaced is kept as well, so a rollback can be undone with another rollback.",
+	Example: `  run-flogo-app rollback
+  run-flogo-app rollback v2.2.0`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version := ""
+		if len(args) == 1 {
+			version = args[0]
+		}
+		software.Rollback(version, a.KeepVersions)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
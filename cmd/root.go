@@ -4,9 +4,13 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/abhijitWakchaure/run-flogo-app/app"
 	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
 	"github.com/abhijitWakchaure/run-flogo-app/software"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
@@ -20,34 +24,41 @@ var GENDOCS bool
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "run-flogo-app",
+	Use:   "run-flogo-app [@alias] [flags] [-- app args]",
 	Short: "Run the most recent flogo app from your apps dir",
 	Long:  `Run the most recent flogo app from your configured apps dir. If the apps dir is not configured, the default will be used`,
+	Args:  cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		debug, _ := cmd.Flags().GetBool("debug")
-		trace, _ := cmd.Flags().GetBool("trace")
-		list, _ := cmd.Flags().GetBool("list")
-		name, _ := cmd.Flags().GetString("name")
-		software.PrintUpdateInfo(a.UpdateConfig)
-		go func() {
-			updateConfig, err := software.CheckForUpdates()
-			if err == nil {
-				software.WriteUpdateConfig(updateConfig)
+		rf := readRunFlags(cmd.Flags())
+		if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+			aliasName := strings.ToLower(strings.TrimPrefix(args[0], "@"))
+			alias, ok := a.Aliases[aliasName]
+			if !ok {
+				fmt.Printf("E> Error ERR_ALIAS_NOT_FOUND: no alias found with name [%s], use 'run-flogo-app alias list' to see all aliases\n", aliasName)
+				os.Exit(1)
 			}
-		}()
-		logLevel := config.LogLevelInfo
-		if trace {
-			logLevel = config.LogLevelTrace
-		} else if debug {
-			logLevel = config.LogLevelDebug
+			rf.applyAlias(cmd.Flags(), alias)
+			args = append(append([]string{}, alias.Args...), args[1:]...)
+		}
+		rf.validate()
+		if rf.sortBy != "" {
+			a.SortBy = rf.sortBy
 		}
-		if list {
-			a.RunWithList(logLevel, args)
+		rf.resolveContainer(a.AppConfig)
+		software.PrintUpdateInfo(a.UpdateConfig)
+		check := software.StartBackgroundCheck(a.AppConfig)
+		app.OnExit(func() {
+			check.Stop(config.UpdateCheckExitWait * time.Second)
+		})
+		opts := rf.runOptions(args)
+		opts.Env = appEnv(rf.envProfile, rf.props, opts.Env)
+		if rf.list {
+			a.RunWithList(opts)
 		}
-		if name != "" {
-			a.RunNamedApp(name, logLevel, args)
+		if rf.name != "" {
+			a.RunNamedApp(rf.name, opts)
 		}
-		a.RunLatestApp(logLevel, args)
+		a.RunLatestApp(opts)
 	},
 	DisableAutoGenTag: true,
 }
@@ -100,17 +111,24 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
-	rootCmd.Flags().BoolP("debug", "d", false, "Enable debug logs")
-	rootCmd.Flags().BoolP("trace", "t", false, "Enable trace logs")
-	rootCmd.Flags().StringP("name", "n", "", "Run app with given (partial) name")
-	rootCmd.Flags().BoolP("list", "l", false, "List last 5 apps and choose a number to run")
+	rootCmd.PersistentFlags().String("config", "", "Path of the config file (default $XDG_CONFIG_HOME/run-flogo-app/config.json)")
+	rootCmd.PersistentFlags().Bool("offline", false, "Disable all network access, e.g. the update checks (env "+config.EnvOffline+")")
+	addRunFlags(rootCmd.Flags())
 }
 
 func initConfig() {
-	home := config.GetUserHomeDir()
-	viper.AddConfigPath(home)
+	offline, _ := rootCmd.PersistentFlags().GetBool("offline")
+	if env, err := strconv.ParseBool(os.Getenv(config.EnvOffline)); err == nil && env {
+		offline = true
+	}
+	download.SetOffline(offline)
+	configFile, _ := rootCmd.PersistentFlags().GetString("config")
+	config.SetConfigFile(configFile)
+	config.MigrateLegacyFiles()
+	config.Migrate()
+
+	viper.SetConfigFile(config.SettingsFilePath())
 	viper.SetConfigType("json")
-	viper.SetConfigName(".run-flogo-app")
 
 	viper.AutomaticEnv()
 
@@ -120,18 +138,83 @@ func initConfig() {
 
 	appsDir := viper.GetString("appsDir")
 	appPattern := viper.GetString("appPattern")
-	isUpdateAvailable := viper.GetBool("isUpdateAvailable")
-	updateURL := viper.GetString("updateURL")
-	releaseNotes := viper.GetString("releaseNotes")
+	sortBy := viper.GetString("sortBy")
+	fc := fileConfig()
+	env := fc.GetStringSlice("env")
+	envProfiles := fc.GetStringMapStringSlice("envProfiles")
+	releaseAPI := viper.GetString("releaseAPI")
+	updateChannel := viper.GetString("updateChannel")
+	updateCheckInterval := viper.GetString("updateCheckInterval")
+	if updateCheckInterval == "" {
+		updateCheckInterval = config.DefaultUpdateCheckInterval
+	}
+	artifactRepo := viper.GetString("artifactRepo")
+	caBundle := viper.GetString("caBundle")
+	containerImage := viper.GetString("containerImage")
+	containerRuntime := viper.GetString("containerRuntime")
+	keepVersions := config.DefaultKeepVersions
+	if viper.IsSet("keepVersions") {
+		keepVersions = viper.GetInt("keepVersions")
+	}
+	aliases := savedAliases()
 
 	appConfig := &config.AppConfig{
 		AppsDir:    appsDir,
 		AppPattern: appPattern,
+		SortBy:     sortBy,
+		Env:        env,
+		Aliases:    aliases,
+
+		ReleaseAPI:    releaseAPI,
+		UpdateChannel: updateChannel,
+		KeepVersions:  keepVersions,
+
+		UpdateCheckInterval: updateCheckInterval,
+		CABundle:            caBundle,
+		ArtifactRepo:        artifactRepo,
+
+		ContainerImage:   containerImage,
+		ContainerRuntime: containerRuntime,
+		EnvProfiles:      envProfiles,
 	}
-	updateConfig := &software.UpdateConfig{
-		IsUpdateAvailable: isUpdateAvailable,
-		UpdateURL:         updateURL,
-		ReleaseNotes:      releaseNotes,
+	a = app.NewApp(appConfig, software.ReadUpdateConfig())
+	mergeProjectConfigs()
+	download.SetCABundle(a.CABundle)
+}
+
+// fileConfig returns the values of the config file only. The global viper
+// has AutomaticEnv, which would e.g. read env from the ENV variable of sh.
+func fileConfig() *viper.Viper {
+	v := viper.New()
+	v.SetConfigFile(config.SettingsFilePath())
+	v.SetConfigType("json")
+	_ = v.ReadInConfig()
+	return v
+}
+
+// savedAliases returns the aliases saved in the config file
+func savedAliases() map[string]*config.Alias {
+	aliases := map[string]*config.Alias{}
+	if err := viper.UnmarshalKey("aliases", &aliases); err != nil {
+		fmt.Printf("E> Error ERR_READ_ALIASES: %s\n", err.Error())
+	}
+	return aliases
+}
+
+// mergeProjectConfigs merges the project config files found in the working
+// dir and its parents over the config file
+func mergeProjectConfigs() {
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	for _, f := range config.FindProjectConfigs(wd, config.SettingsFilePath(), config.LegacySettingsFilePath()) {
+		projectConfig, err := config.ReadProjectConfig(f)
+		if err != nil {
+			fmt.Printf("E> Error ERR_READ_PROJECT_CONFIG: %s\n", err.Error())
+			continue
+		}
+		fmt.Printf("i> Using project config file at: %s\n\n", f)
+		a.AppConfig.Merge(projectConfig)
 	}
-	a = app.NewApp(appConfig, updateConfig)
 }
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/container"
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+	"github.com/abhijitWakchaure/run-flogo-app/sandbox"
+	"github.com/spf13/pflag"
+)
+
+// runFlags holds the flags which decide which app to run and how
+type runFlags struct {
+	debug   bool
+	trace   bool
+	list    bool
+	name    string
+	sortBy  string
+	env     []string
+	ports   []int
+	detach  bool
+	ready   *app.ReadyOptions
+	stats   *app.StatsOptions
+	sandbox *sandbox.Options
+	// container is set to run the app in a container
+	container *app.ContainerOptions
+	// limitErr is the error parsing the limits, reported by validate
+	limitErr error
+	// envProfile is the name of the env profile and props the file of the
+	// app property overrides
+	envProfile string
+	props      string
+}
+
+// addRunFlags adds the flags used for running an app to the given flag set
+func addRunFlags(fs *pflag.FlagSet) {
+	fs.BoolP("debug", "d", false, "Enable debug logs")
+	fs.BoolP("trace", "t", false, "Enable trace logs")
+	fs.StringP("name", "n", "", "Run app with given (partial) name")
+	fs.BoolP("list", "l", false, "List last 5 apps and choose a number to run")
+	fs.String("sort", "", "Strategy to find the latest app: "+strings.Join(config.SortStrategies, ", ")+", buildtime is the commit time (vcs.time) embedded in the app (default from config)")
+	fs.StringArrayP("env", "e", nil, "Set environment variable for the app in KEY=VALUE format")
+	addEnvFlags(fs)
+	fs.IntSlice("port", nil, "Port the app listens on, checked to be free before the launch (default the trigger ports of the app)")
+	fs.Bool("detach", false, "Run the app in the background, manage it with the ps, logs, stop and restart commands")
+	fs.Bool("ready", false, "Wait for the app to be ready, by probing its trigger ports or else watching its logs")
+	fs.String("ready-http", "", "Wait for a GET on the URL or path (on the trigger port) to succeed")
+	fs.Lookup("ready-http").NoOptDefVal = "/"
+	fs.String("ready-tcp", "", "Wait for a TCP connect to host:port or port to succeed (default the trigger ports)")
+	fs.Lookup("ready-tcp").NoOptDefVal = app.ReadyAuto
+	fs.String("ready-log", "", "Wait for a line of the app output to match the regex")
+	fs.Lookup("ready-log").NoOptDefVal = config.DefaultReadyLogPattern
+	fs.Int("ready-port", 0, "Trigger port used by the probes (default detected from the app)")
+	fs.Duration("ready-timeout", config.DefaultReadyTimeout*time.Second, "Stop the app if it is not ready within the timeout")
+	fs.Bool("stats", false, "Print the resource usage of the app every interval and a summary once it exits")
+	fs.Duration("stats-interval", config.DefaultStatsInterval*time.Second, "Interval between the resource usage samples")
+	fs.String("stats-file", "", "Write the resource usage samples to the CSV file")
+	fs.String("stats-metrics", "", "URL, or path on the flogo HTTP service port, of the prometheus metrics or goroutine profile (default "+config.DefaultMetricsPath+")")
+	fs.String("limit-memory", "", "Limit the memory of the app, e.g. 512M, the RSS in a cgroup or else the virtual memory")
+	fs.Duration("limit-cpu-time", 0, "Limit the CPU time of the app, which is killed once it is used")
+	fs.Uint64("limit-files", 0, "Limit the number of files the app can open")
+	fs.Float64("limit-cpus", 0, "Limit the CPUs the app can use, e.g. 1.5, in a cgroup")
+	fs.Int("limit-pids", 0, "Limit the number of processes and threads of the app, in a cgroup")
+	fs.Bool("cgroup", false, "Run the app in a cgroup v2, which is needed to limit its RSS like in a container (linux, as root)")
+	fs.StringSlice("isolate", nil, "Run the app in new linux namespaces: "+strings.Join(sandbox.Namespaces, ", "))
+	fs.String("workdir", "", "Working directory of the app, created if missing (default the current dir)")
+	fs.String("container", "", "Run the app in a container of the image with docker or podman, publishing its trigger ports (default image from config or "+config.DefaultContainerImage+")")
+	fs.Lookup("container").NoOptDefVal = app.ContainerImageDefault
+	fs.String("container-runtime", "", "Container runtime used with --container, a name or path (default from config or the first found of "+strings.Join(container.Runtimes, ", ")+")")
+	fs.Bool("clean-env", false, "Run the app with only the variables set by the program, -e and the config, instead of the whole environment")
+}
+
+func readRunFlags(fs *pflag.FlagSet) *runFlags {
+	rf := new(runFlags)
+	rf.debug, _ = fs.GetBool("debug")
+	rf.trace, _ = fs.GetBool("trace")
+	rf.list, _ = fs.GetBool("list")
+	rf.name, _ = fs.GetString("name")
+	rf.sortBy, _ = fs.GetString("sort")
+	rf.env, _ = fs.GetStringArray("env")
+	rf.envProfile, _ = fs.GetString("env-profile")
+	rf.props, _ = fs.GetString("props")
+	rf.ports, _ = fs.GetIntSlice("port")
+	rf.detach, _ = fs.GetBool("detach")
+	rf.readOptions(fs)
+	return rf
+}
+
+// readOptions reads the flags which are combined into the options of the
+// probes, the stats, the sandbox and the container
+func (rf *runFlags) readOptions(fs *pflag.FlagSet) {
+	rf.ready, rf.stats, rf.sandbox, rf.container, rf.limitErr = nil, nil, nil, nil, nil
+	ready := new(app.ReadyOptions)
+	ready.HTTP, _ = fs.GetString("ready-http")
+	ready.TCP, _ = fs.GetString("ready-tcp")
+	ready.Log, _ = fs.GetString("ready-log")
+	ready.Port, _ = fs.GetInt("ready-port")
+	ready.Timeout, _ = fs.GetDuration("ready-timeout")
+	for _, name := range []string{"ready", "ready-http", "ready-tcp", "ready-log", "ready-port", "ready-timeout"} {
+		if fs.Changed(name) {
+			rf.ready = ready
+		}
+	}
+	if on, _ := fs.GetBool("ready"); fs.Changed("ready") && !on {
+		rf.ready = nil
+	}
+	stats := new(app.StatsOptions)
+	stats.Interval, _ = fs.GetDuration("stats-interval")
+	stats.File, _ = fs.GetString("stats-file")
+	stats.MetricsURL, _ = fs.GetString("stats-metrics")
+	for _, name := range []string{"stats", "stats-interval", "stats-file", "stats-metrics"} {
+		if fs.Changed(name) {
+			rf.stats = stats
+		}
+	}
+	if on, _ := fs.GetBool("stats"); fs.Changed("stats") && !on {
+		rf.stats = nil
+	}
+	sb := new(sandbox.Options)
+	if v, _ := fs.GetString("limit-memory"); v != "" {
+		sb.Memory, rf.limitErr = sandbox.ParseBytes(v)
+	}
+	sb.CPUTime, _ = fs.GetDuration("limit-cpu-time")
+	sb.OpenFiles, _ = fs.GetUint64("limit-files")
+	sb.CPUs, _ = fs.GetFloat64("limit-cpus")
+	sb.PIDs, _ = fs.GetInt("limit-pids")
+	sb.Cgroup, _ = fs.GetBool("cgroup")
+	sb.Namespaces, _ = fs.GetStringSlice("isolate")
+	sb.Workdir, _ = fs.GetString("workdir")
+	sb.CleanEnv, _ = fs.GetBool("clean-env")
+	if image, _ := fs.GetString("container"); image != "" {
+		rf.container = &app.ContainerOptions{Image: image}
+		rf.container.Runtime, _ = fs.GetString("container-runtime")
+	}
+	// The description is empty if no limit is set
+	if sb.String() != "" {
+		rf.sandbox = sb
+	}
+}
+
+// logLevel returns the log level selected by the flags
+func (rf *runFlags) logLevel() string {
+	if rf.trace {
+		return config.LogLevelTrace
+	}
+	if rf.debug {
+		return config.LogLevelDebug
+	}
+	return config.LogLevelInfo
+}
+
+// applyAlias fills the flags from alias which were not set on command line
+func (rf *runFlags) applyAlias(fs *pflag.FlagSet, alias *config.Alias) {
+	if !fs.Changed("name") {
+		rf.name = alias.Name
+	}
+	if !fs.Changed("list") {
+		rf.list = alias.List
+	}
+	if !fs.Changed("sort") {
+		rf.sortBy = alias.SortBy
+	}
+	if !fs.Changed("debug") && !fs.Changed("trace") {
+		rf.debug = alias.LogLevel == config.LogLevelDebug
+		rf.trace = alias.LogLevel == config.LogLevelTrace
+	}
+	rf.env = append(append([]string{}, alias.Env...), rf.env...)
+	if !fs.Changed("env-profile") {
+		rf.envProfile = alias.EnvProfile
+	}
+	if !fs.Changed("props") {
+		rf.props = alias.Props
+	}
+	if !fs.Changed("port") {
+		rf.ports = alias.Ports
+	}
+	if !fs.Changed("detach") {
+		rf.detach = alias.Detach
+	}
+	// The other flags are set on the flag set unless given on the command
+	// line, and read again as they are combined into their options
+	for _, flag := range alias.Flags() {
+		name, value := strings.TrimPrefix(flag, "--"), "true"
+		if i := strings.Index(name, "="); i >= 0 {
+			name, value = name[:i], name[i+1:]
+		}
+		if fs.Changed(name) {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			fmt.Printf("E> Error ERR_INVALID_ALIAS: invalid value of --%s in the alias: %s\n", name, err.Error())
+			os.Exit(1)
+		}
+	}
+	rf.readOptions(fs)
+}
+
+// addEnvFlags adds the flags of the env profile and of the app properties
+func addEnvFlags(fs *pflag.FlagSet) {
+	fs.String("env-profile", "", "Set the environment variables of the env profile of the config, before the ones of -e")
+	fs.String("props", "", "Override the app properties with the ones of the JSON file, set in "+flogo.EnvPropsJSON)
+}
+
+// validateEnv will exit if a variable of env is not in KEY=VALUE format
+func validateEnv(env []string) {
+	if err := config.CheckEnv(env); err != nil {
+		fmt.Printf("E> Error ERR_INVALID_ENV: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// validateProps will exit if the file of the app properties can not be read
+func validateProps(path string) map[string]interface{} {
+	if path == "" {
+		return nil
+	}
+	props, err := flogo.ReadProperties(path)
+	if err != nil {
+		fmt.Printf("E> Error ERR_INVALID_PROPS: %s\n", err.Error())
+		os.Exit(1)
+	}
+	return props
+}
+
+// appEnv returns the env of the app: the env of the config, of the env
+// profile and then env, with the app properties of the props file
+func appEnv(envProfile, props string, env []string) []string {
+	merged := append([]string{}, a.Env...)
+	if envProfile != "" {
+		// The names are in lower case as read by viper
+		profile, ok := a.EnvProfiles[strings.ToLower(envProfile)]
+		if !ok {
+			fmt.Printf("E> Error ERR_ENV_PROFILE_NOT_FOUND: no env profile found with name [%s] in the envProfiles of the config\n", envProfile)
+			os.Exit(1)
+		}
+		merged = append(merged, profile...)
+	}
+	merged = append(merged, env...)
+	validateEnv(merged)
+	if overrides := validateProps(props); len(overrides) > 0 {
+		var err error
+		merged, err = flogo.SetProperties(merged, overrides)
+		if err != nil {
+			fmt.Printf("E> Error ERR_INVALID_PROPS: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+	return merged
+}
+
+// validate will exit if any of the flags has an invalid value
+func (rf *runFlags) validate() {
+	if rf.sortBy != "" && !config.IsValidSortBy(rf.sortBy) {
+		fmt.Printf("E> Error ERR_INVALID_SORT: unknown sort strategy [%s], valid strategies are: %s\n", rf.sortBy, strings.Join(config.SortStrategies, ", "))
+		os.Exit(1)
+	}
+	validateEnv(rf.env)
+	validateProps(rf.props)
+	for _, port := range rf.ports {
+		if port <= 0 || port > 65535 {
+			fmt.Printf("E> Error ERR_INVALID_PORT: port [%d] must be between 1 and 65535\n", port)
+			os.Exit(1)
+		}
+	}
+	if rf.ready != nil {
+		if rf.ready.Timeout <= 0 {
+			fmt.Printf("E> Error ERR_INVALID_READY: --ready-timeout must be greater than 0\n")
+			os.Exit(1)
+		}
+		if rf.ready.Port < 0 || rf.ready.Port > 65535 {
+			fmt.Printf("E> Error ERR_INVALID_READY: --ready-port [%d] is not a valid port\n", rf.ready.Port)
+			os.Exit(1)
+		}
+	}
+	if rf.stats != nil && rf.stats.Interval <= 0 {
+		fmt.Printf("E> Error ERR_INVALID_STATS: --stats-interval must be greater than 0\n")
+		os.Exit(1)
+	}
+	if rf.container != nil && rf.detach {
+		fmt.Printf("E> Error ERR_INVALID_CONTAINER: --container cannot be used with --detach, run the container in the background with its runtime\n")
+		os.Exit(1)
+	}
+	if rf.limitErr != nil {
+		fmt.Printf("E> Error ERR_INVALID_LIMIT: %s\n", rf.limitErr.Error())
+		os.Exit(1)
+	}
+	if rf.sandbox != nil {
+		if err := rf.sandbox.Validate(); err != nil {
+			fmt.Printf("E> Error ERR_INVALID_LIMIT: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+// runOptions returns the options for running the app with given args
+func (rf *runFlags) runOptions(args []string) *app.RunOptions {
+	return &app.RunOptions{
+		LogLevel:  rf.logLevel(),
+		Env:       rf.env,
+		Ports:     rf.ports,
+		Args:      args,
+		Ready:     rf.ready,
+		Detach:    rf.detach,
+		Stats:     rf.stats,
+		Sandbox:   rf.sandbox,
+		Container: rf.container,
+	}
+}
+
+// resolveContainer fills the container image and runtime from the config
+// when they are not set with the flags
+func (rf *runFlags) resolveContainer(c *config.AppConfig) {
+	if rf.container == nil {
+		return
+	}
+	if rf.container.Image == app.ContainerImageDefault {
+		rf.container.Image = c.ContainerImage
+		if rf.container.Image == "" {
+			rf.container.Image = config.DefaultContainerImage
+		}
+	}
+	if rf.container.Runtime == "" {
+		rf.container.Runtime = c.ContainerRuntime
+	}
+}
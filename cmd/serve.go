@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/files"
+	"github.com/abhijitWakchaure/run-flogo-app/server"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local HTTP API to list, run and manage the apps",
+	Long: "Serve a local HTTP/JSON API to list the apps, run them in the background, stop and restart them and stream their logs. " +
+		"A dashboard using the API is served on the root path to do the same from a browser, along with the run history and the config. " +
+		"The API is only served on localhost or on a unix socket, the apps it runs are listed by the ps command as well. " +
+		"On localhost, the API requires the bearer token written to " + config.ServeTokenFileName + " in the state dir, which is new for every start, so that the other local users cannot run apps.",
+	Example: `  run-flogo-app serve
+  TOKEN=$(cat ~/.local/state/run-flogo-app/serve.token)
+  curl -s -H "Authorization: Bearer $TOKEN" localhost:7470/api/apps
+  curl -s -X POST -H "Authorization: Bearer $TOKEN" -d '{"app": "order-service", "logLevel": "DEBUG"}' localhost:7470/api/apps/run
+  curl -s --unix-socket /tmp/run-flogo-app.sock localhost/api/processes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		socket, _ := cmd.Flags().GetString("socket")
+		l, err := server.Listen(addr, socket)
+		if err != nil {
+			fmt.Printf("E> Error ERR_SERVE: %s\n", err.Error())
+			os.Exit(1)
+		}
+		s := server.New(a.AppsDir, files.CompilePattern(a.AppPattern), a.SortBy)
+		// The unix socket is only accessible by the user, on addr the other
+		// local users need the token
+		var token string
+		tokenFile := filepath.Join(config.StateDir(), config.ServeTokenFileName)
+		if socket == "" {
+			token, err = server.WriteToken(tokenFile)
+			if err != nil {
+				fmt.Printf("E> Error ERR_SERVE: unable to write the token: %s\n", err.Error())
+				os.Exit(1)
+			}
+			s.RequireToken(token)
+		}
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			<-stop
+			fmt.Println("\n#> Shutting down the API server...")
+			ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout*time.Second)
+			defer cancel()
+			s.Shutdown(ctx)
+		}()
+		if socket != "" {
+			fmt.Printf("#> Serving the API on unix socket [%s]\n", socket)
+		} else {
+			fmt.Printf("#> Serving the API on http://%s/api with the bearer token in [%s]\n", l.Addr(), tokenFile)
+			fmt.Printf("#> Open the dashboard at http://%s/#token=%s\n", l.Addr(), token)
+		}
+		fmt.Println("#> The apps run by the API keep running after the server is stopped, stop them with 'run-flogo-app stop'")
+		err = s.Serve(l)
+		if err != nil {
+			fmt.Printf("E> Error ERR_SERVE: %s\n", err.Error())
+			app.Exit(1)
+		}
+		// Serve returns as soon as the shutdown starts
+		<-done
+		app.Exit(0)
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", config.DefaultServeAddr, "Loopback address to serve the API on")
+	serveCmd.Flags().String("socket", "", "Unix socket to serve the API on instead of addr")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats <id|name>",
+	Short: "Show the resource usage of an app running in the background",
+	Long: "Show the CPU, memory (RSS), open file descriptors and threads of an app running in the background, read from /proc on linux. " +
+		"The goroutine count is read from the metrics of the flogo HTTP service when the app has " + config.MetricsPortEnv + " set, or from --metrics.",
+	Example: `  run-flogo-app stats 1
+  run-flogo-app stats -w --interval 10s order-service`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		watch, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		metrics, _ := cmd.Flags().GetString("metrics")
+		if interval <= 0 {
+			fmt.Printf("E> Error ERR_INVALID_STATS: --interval must be greater than 0\n")
+			os.Exit(1)
+		}
+		app.ShowStats(args[0], watch, interval, metrics)
+	},
+}
+
+func init() {
+	statsCmd.Flags().BoolP("watch", "w", false, "Keep printing the usage every interval and a summary once interrupted")
+	statsCmd.Flags().Duration("interval", config.DefaultStatsInterval*time.Second, "Interval between the samples with --watch")
+	statsCmd.Flags().String("metrics", "", "URL, or path on the flogo HTTP service port, of the prometheus metrics or goroutine profile (default "+config.DefaultMetricsPath+")")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/spf13/cobra"
+)
+
+// stopCmd represents the stop command
+var stopCmd = &cobra.Command{
+	Use:   "stop <id|name>...",
+	Short: "Stop the apps running in the background",
+	Long: "Stop the apps started with --detach, by their id or (partial) name as listed by ps, and remove them from the list. " +
+		"The apps are asked to terminate and are killed if they are still running after 10 seconds. Their log files are kept.",
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+		if len(args) == 0 && !all {
+			fmt.Println("E> Error ERR_STOP_APP: please provide the id or name of the app, or use --all")
+			os.Exit(1)
+		}
+		app.StopProcesses(args, all)
+	},
+}
+
+func init() {
+	stopCmd.Flags().Bool("all", false, "Stop all the apps running in the background")
+	rootCmd.AddCommand(stopCmd)
+}
@@ -10,7 +10,7 @@ var uninstallCmd = &cobra.Command{
 	Use:   "uninstall",
 	Short: "Uninstall the program",
 	Run: func(cmd *cobra.Command, args []string) {
-		software.Uninstall(a.InstallPath)
+		software.Uninstall()
 	},
 }
 
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
 	"github.com/spf13/cobra"
 )
 
@@ -8,11 +12,28 @@ import (
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update the app with latest version",
+	Long: "Update the app with latest version released in the update channel, or with the given version. " +
+		"The releases are looked up from the releaseAPI in config file, which can point to an internal mirror of the GitHub releases API. " +
+		"The release API must be https and the download is verified with the signed checksum manifest of the release, a release without it is only installed with --allow-unsigned.",
+	Example: `  run-flogo-app update
+  run-flogo-app update --channel prerelease
+  run-flogo-app update --version v2.2.0
+  run-flogo-app update --version v2.3.0-rc.1 --allow-unsigned`,
 	Run: func(cmd *cobra.Command, args []string) {
-		a.Update()
+		version, _ := cmd.Flags().GetString("version")
+		channel, _ := cmd.Flags().GetString("channel")
+		allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+		if channel != "" && channel != config.UpdateChannelStable && channel != config.UpdateChannelPrerelease {
+			fmt.Printf("E> Error ERR_INVALID_CHANNEL: unknown update channel [%s], valid channels are: %s, %s\n", channel, config.UpdateChannelStable, config.UpdateChannelPrerelease)
+			os.Exit(1)
+		}
+		a.Update(version, channel, allowUnsigned)
 	},
 }
 
 func init() {
+	updateCmd.Flags().String("version", "", "Install the given version, e.g. v2.2.0, instead of the latest one")
+	updateCmd.Flags().String("channel", "", "Release channel to update from: "+config.UpdateChannelStable+" or "+config.UpdateChannelPrerelease+" (default from config)")
+	updateCmd.Flags().Bool("allow-unsigned", false, "Install the release even if it has no signed checksum manifest")
 	rootCmd.AddCommand(updateCmd)
 }
@@ -12,7 +12,7 @@ var versionCmd = &cobra.Command{
 	Short: "Print the version info of the program",
 	Run: func(cmd *cobra.Command, args []string) {
 		software.PrintUpdateInfo(a.UpdateConfig)
-		app.PrintVersion()
+		app.PrintVersion(a.UpdateConfig)
 	},
 }
 
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/abhijitWakchaure/run-flogo-app/software"
+	"github.com/spf13/cobra"
+)
+
+// versionsCmd represents the versions command
+var versionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "List the previously installed versions kept for rollback",
+	Long: "List the previously installed versions of the program kept for rollback, the most recent first. " +
+		"The number of versions kept is set by keepVersions in config file.",
+	Run: func(cmd *cobra.Command, args []string) {
+		software.PrintVersions()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionsCmd)
+}
@@ -3,18 +3,187 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // VERSION ...
 var VERSION = "undefined"
 
+// ReleasePublicKey is the base64 encoded ed25519 public key used to verify the
+// signature of the checksum manifest of the releases, build.sh sets it with
+// -ldflags "-X .../config.ReleasePublicKey=<key>"
+var ReleasePublicKey = ""
+
 // AppConfig ...
 type AppConfig struct {
-	AppsDir    string `json:"appsDir"`
-	AppPattern string `json:"appPattern"`
+	AppsDir       string            `json:"appsDir"`
+	AppPattern    string            `json:"appPattern"`
+	SortBy        string            `json:"sortBy"`
+	Env           []string          `json:"env,omitempty"`
+	Aliases       map[string]*Alias `json:"aliases,omitempty"`
+	ReleaseAPI    string            `json:"releaseAPI,omitempty"`
+	UpdateChannel string            `json:"updateChannel,omitempty"`
+	ArtifactRepo  string            `json:"artifactRepo,omitempty"`
+	KeepVersions  int               `json:"keepVersions,omitempty"`
+	// UpdateCheckInterval is the minimum duration between the background update checks
+	UpdateCheckInterval string `json:"updateCheckInterval,omitempty"`
+	// CABundle is a PEM file with the CA certificates trusted for the downloads
+	CABundle string `json:"caBundle,omitempty"`
+	// ContainerImage and ContainerRuntime are used to run the apps with --container
+	ContainerImage   string `json:"containerImage,omitempty"`
+	ContainerRuntime string `json:"containerRuntime,omitempty"`
+	// EnvProfiles are named lists of environment variables, e.g. per stage,
+	// applied with --env-profile
+	EnvProfiles map[string][]string `json:"envProfiles,omitempty"`
+}
+
+// Alias is a saved launch recipe which can be invoked as run-flogo-app @<alias>
+type Alias struct {
+	Name     string   `json:"name,omitempty"`
+	List     bool     `json:"list,omitempty"`
+	SortBy   string   `json:"sortBy,omitempty"`
+	LogLevel string   `json:"logLevel,omitempty"`
+	Env      []string `json:"env,omitempty"`
+	Ports    []int    `json:"ports,omitempty"`
+	Detach   bool     `json:"detach,omitempty"`
+	Args     []string `json:"args,omitempty"`
+	// EnvProfile is the name of the env profile and Props the file of the app
+	// property overrides
+	EnvProfile string `json:"envProfile,omitempty"`
+	Props      string `json:"props,omitempty"`
+	// Ready and the Ready* fields are the readiness probes of --ready and the
+	// --ready-* flags
+	Ready        bool   `json:"ready,omitempty"`
+	ReadyHTTP    string `json:"readyHttp,omitempty"`
+	ReadyTCP     string `json:"readyTcp,omitempty"`
+	ReadyLog     string `json:"readyLog,omitempty"`
+	ReadyPort    int    `json:"readyPort,omitempty"`
+	ReadyTimeout string `json:"readyTimeout,omitempty"`
+	// Stats and the Stats* fields are the resource usage reporting of --stats
+	// and the --stats-* flags
+	Stats         bool   `json:"stats,omitempty"`
+	StatsInterval string `json:"statsInterval,omitempty"`
+	StatsFile     string `json:"statsFile,omitempty"`
+	StatsMetrics  string `json:"statsMetrics,omitempty"`
+	// The Limit* fields, Cgroup, Isolate, Workdir and CleanEnv are the
+	// sandbox of the --limit-* flags, --cgroup, --isolate, --workdir and
+	// --clean-env
+	LimitMemory  string   `json:"limitMemory,omitempty"`
+	LimitCPUTime string   `json:"limitCpuTime,omitempty"`
+	LimitFiles   int      `json:"limitFiles,omitempty"`
+	LimitCPUs    string   `json:"limitCpus,omitempty"`
+	LimitPIDs    int      `json:"limitPids,omitempty"`
+	Cgroup       bool     `json:"cgroup,omitempty"`
+	Isolate      []string `json:"isolate,omitempty"`
+	Workdir      string   `json:"workdir,omitempty"`
+	CleanEnv     bool     `json:"cleanEnv,omitempty"`
+	// Container is the image of --container, the runtime is only read from
+	// the config file
+	Container string `json:"container,omitempty"`
+}
+
+// Recipe returns the alias as command line flags and args
+func (al *Alias) Recipe() string {
+	var parts []string
+	if al.Name != "" {
+		parts = append(parts, "-n", quoteArg(al.Name))
+	}
+	if al.List {
+		parts = append(parts, "-l")
+	}
+	if al.SortBy != "" {
+		parts = append(parts, "--sort", al.SortBy)
+	}
+	switch al.LogLevel {
+	case LogLevelDebug:
+		parts = append(parts, "-d")
+	case LogLevelTrace:
+		parts = append(parts, "-t")
+	}
+	if al.EnvProfile != "" {
+		parts = append(parts, "--env-profile", quoteArg(al.EnvProfile))
+	}
+	for _, e := range al.Env {
+		parts = append(parts, "-e", quoteArg(e))
+	}
+	if al.Props != "" {
+		parts = append(parts, "--props", quoteArg(al.Props))
+	}
+	for _, port := range al.Ports {
+		parts = append(parts, "--port", strconv.Itoa(port))
+	}
+	if al.Detach {
+		parts = append(parts, "--detach")
+	}
+	for _, flag := range al.Flags() {
+		parts = append(parts, quoteArg(flag))
+	}
+	if len(al.Args) > 0 {
+		parts = append(parts, "--")
+		for _, arg := range al.Args {
+			parts = append(parts, quoteArg(arg))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Flags returns the flags of the alias which are set on the flags of the run
+// command, as --flag or --flag=value so that the flags with an optional value
+// can be parsed again
+func (al *Alias) Flags() []string {
+	var flags []string
+	if al.Ready {
+		flags = append(flags, "--ready")
+	}
+	flags = appendFlag(flags, "ready-http", al.ReadyHTTP)
+	flags = appendFlag(flags, "ready-tcp", al.ReadyTCP)
+	flags = appendFlag(flags, "ready-log", al.ReadyLog)
+	if al.ReadyPort != 0 {
+		flags = appendFlag(flags, "ready-port", strconv.Itoa(al.ReadyPort))
+	}
+	flags = appendFlag(flags, "ready-timeout", al.ReadyTimeout)
+	if al.Stats {
+		flags = append(flags, "--stats")
+	}
+	flags = appendFlag(flags, "stats-interval", al.StatsInterval)
+	flags = appendFlag(flags, "stats-file", al.StatsFile)
+	flags = appendFlag(flags, "stats-metrics", al.StatsMetrics)
+	flags = appendFlag(flags, "limit-memory", al.LimitMemory)
+	flags = appendFlag(flags, "limit-cpu-time", al.LimitCPUTime)
+	if al.LimitFiles != 0 {
+		flags = appendFlag(flags, "limit-files", strconv.Itoa(al.LimitFiles))
+	}
+	flags = appendFlag(flags, "limit-cpus", al.LimitCPUs)
+	if al.LimitPIDs != 0 {
+		flags = appendFlag(flags, "limit-pids", strconv.Itoa(al.LimitPIDs))
+	}
+	if al.Cgroup {
+		flags = append(flags, "--cgroup")
+	}
+	flags = appendFlag(flags, "isolate", strings.Join(al.Isolate, ","))
+	flags = appendFlag(flags, "workdir", al.Workdir)
+	if al.CleanEnv {
+		flags = append(flags, "--clean-env")
+	}
+	flags = appendFlag(flags, "container", al.Container)
+	return flags
+}
+
+func appendFlag(flags []string, name, value string) []string {
+	if value == "" {
+		return flags
+	}
+	return append(flags, "--"+name+"="+value)
+}
+
+func quoteArg(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\"'") {
+		return strconv.Quote(s)
+	}
+	return s
 }
 
 // Print prints the current config
@@ -33,13 +202,25 @@ func Write(appConfig *AppConfig) {
 	if appConfig.AppsDir == "" {
 		appConfig.AppsDir = filepath.Join(userHome, "Downloads")
 	}
-	configJSON, _ := json.MarshalIndent(appConfig, "", "\t")
-	err := ioutil.WriteFile(filepath.Join(userHome, ConfigFileName), configJSON, 0644)
+	err := UpdateSettings(func(m map[string]interface{}) {
+		m["appsDir"] = appConfig.AppsDir
+		m["appPattern"] = appConfig.AppPattern
+	})
 	if err != nil {
 		fmt.Printf("E> Error ERR_WRITE_CONFIG: %s\n", err.Error())
 	}
 }
 
+// IsValidSortBy returns true if the given sort strategy is supported
+func IsValidSortBy(sortBy string) bool {
+	for _, s := range SortStrategies {
+		if s == sortBy {
+			return true
+		}
+	}
+	return false
+}
+
 // GetUserHomeDir ...
 func GetUserHomeDir() string {
 	home, err := os.UserHomeDir()
@@ -6,20 +6,78 @@ const (
 	ConfigFileName  = ".run-flogo-app"
 	MaxAppsWithList = 5
 
+	SettingsFileName    = "config.json"
+	StateFileName       = "state.json"
+	LegacyStateFileName = ".run-flogo-app.state"
+	EnvConfigFile       = "RUN_FLOGO_APP_CONFIG"
+
 	DefaultAppPatternLinux   = `^.+-linux_amd64.*$`
 	DefaultAppPatternWindows = `^.+-windows_amd64.*$`
 	DefaultAppPatternDarwin  = `^.+-darwin_amd64.*$`
 
-	GithubLastestReleaseURL = "https://api.github.com/repos/abhijitWakchaure/run-flogo-app/releases/latest"
-	GithubDownloadBaseURL   = "https://github.com/abhijitWakchaure/run-flogo-app/releases/download/"
-	GithubBaseURL           = "https://github.com/abhijitWakchaure/run-flogo-app"
-	GithubIssuesURL         = "https://github.com/abhijitWakchaure/run-flogo-app/issues"
+	DefaultReleaseAPI     = "https://api.github.com/repos/abhijitWakchaure/run-flogo-app"
+	GithubDownloadBaseURL = "https://github.com/abhijitWakchaure/run-flogo-app/releases/download/"
+	GithubBaseURL         = "https://github.com/abhijitWakchaure/run-flogo-app"
+	GithubIssuesURL       = "https://github.com/abhijitWakchaure/run-flogo-app/issues"
+
+	ChecksumsAssetName = "checksums.txt"
+	SignatureAssetName = "checksums.txt.sig"
+
+	UpdateChannelStable     = "stable"
+	UpdateChannelPrerelease = "prerelease"
+	EnvReleaseAPI           = "RUN_FLOGO_APP_RELEASE_API"
+
+	DefaultUpdateCheckInterval = "24h"
+	EnvOffline                 = "RUN_FLOGO_APP_OFFLINE"
+	ReleaseCacheFileName       = "releases.json"
+	HTTPTimeout                = 15 // seconds
+	UpdateCheckTimeout         = 30 // seconds
+	UpdateCheckExitWait        = 2  // seconds
+
+	FetchedFileName     = "fetched.json"
+	VersionsDirName     = "versions"
+	DefaultKeepVersions = 3
+	SelfCheckTimeout    = 30 // seconds
+
+	ProcessesFileName = "processes.json"
+	LogsDirName       = "logs"
+	StopTimeout       = 10 // seconds
+	HistoryFileName   = "history.json"
+	MaxHistory        = 200
+
+	DefaultServeAddr = "127.0.0.1:7470"
+	ShutdownTimeout  = 5 // seconds
+	// ServeTokenFileName holds the token of the API served on addr
+	ServeTokenFileName = "serve.token"
+
+	DefaultReadyTimeout    = 60 // seconds
+	DefaultReadyLogPattern = `(?i)(started flogo engine|engine started)`
+
+	DefaultStatsInterval = 5 // seconds
+	// MetricsPortEnv enables the HTTP service of flogo apps, whose metrics are
+	// read for the goroutine count
+	MetricsPortEnv     = "FLOGO_HTTP_SERVICE_PORT"
+	DefaultMetricsPath = "/metrics"
+
+	DefaultContainerImage = "alpine:3"
 
 	InstallPathLinux   = "/usr/local/bin"
 	InstallPathDarwin  = "/usr/local/bin"
 	InstallPathWindows = `C:\Windows\system32`
+	// InstallPathUser is relative to the home dir and InstallPathUserWindows to %LocalAppData%
+	InstallPathUser        = ".local/bin"
+	InstallPathUserWindows = `Programs\run-flogo-app`
 
 	LogLevelInfo  = "INFO"
 	LogLevelDebug = "DEBUG"
 	LogLevelTrace = "TRACE"
+
+	SortByModTime    = "mtime"
+	SortByCreateTime = "ctime"
+	SortByBuildTime  = "buildtime"
+	SortBySemver     = "semver"
+	DefaultSortBy    = SortByModTime
 )
+
+// SortStrategies lists all the supported strategies for finding the latest app
+var SortStrategies = []string{SortByModTime, SortByCreateTime, SortByBuildTime, SortBySemver}
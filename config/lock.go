@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// lockTimeout is how long to wait for another process to release the lock
+const lockTimeout = 10 * time.Second
+
+// writeMu serializes the config writes within the process, e.g. the update
+// check running in background and the main command
+var writeMu sync.Mutex
+
+// withLock runs fn while holding the in-process mutex and an advisory lock on
+// path.lock so that the concurrent invocations of the program do not interleave
+func withLock(path string, fn func() error) error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	unlock, err := lockPath(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+// lockPath takes the advisory lock on path.lock, waiting for lockTimeout if
+// another process holds it, and returns the func to release it
+func lockPath(path string) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err = tryLockFile(f)
+		if err == nil {
+			break
+		}
+		if err != errLocked || time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock [%s]: %s", path, err.Error())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// writeFileAtomic writes the data into a temp file in the same dir and renames
+// it over path, so readers never see a truncated or partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), perm)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
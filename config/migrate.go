@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SchemaVersion is the version of the settings file written by this program
+//
+//	1: settings and update state in a single file, keys written in lower case by viper
+//	2: only settings with camel case keys, update state moved to the state file
+const SchemaVersion = 2
+
+// stateKeys are the keys moved from the settings file into the state file
+var stateKeys = []string{"isUpdateAvailable", "updateURL", "releaseNotes"}
+
+// migrations upgrade the settings from the version in the key to the next version
+var migrations = map[int]func(settings, state map[string]interface{}){
+	1: migrateV1,
+}
+
+// Migrate upgrades the settings file to the current schema version and keeps
+// a backup of the old file next to it. Files written by a newer version of
+// the program are left untouched.
+func Migrate() {
+	path := SettingsFilePath()
+	if version := schemaVersionOf(path); version == 0 || version == SchemaVersion {
+		return
+	}
+	var from int
+	var backup string
+	err := withLock(path, func() error {
+		// Check again while holding the lock as another process may have migrated the file
+		from = schemaVersionOf(path)
+		if from == 0 || from == SchemaVersion {
+			return nil
+		}
+		if from > SchemaVersion {
+			fmt.Printf("W> Config file [%s] has schema version %d which is newer than %d supported by this version of the program, please update the program\n\n", path, from, SchemaVersion)
+			return nil
+		}
+		settings, err := ReadFile(path)
+		if err != nil {
+			return err
+		}
+		backup = fmt.Sprintf("%s.v%d.bak", path, from)
+		err = copyFile(path, backup)
+		if err != nil {
+			return fmt.Errorf("failed to backup [%s]: %s", path, err.Error())
+		}
+		unlockState, err := lockPath(StateFilePath())
+		if err != nil {
+			return err
+		}
+		defer unlockState()
+		state, err := ReadFile(StateFilePath())
+		if err != nil {
+			state = map[string]interface{}{}
+		}
+		for version := from; version < SchemaVersion; version++ {
+			migrations[version](settings, state)
+		}
+		settings["schemaVersion"] = SchemaVersion
+		err = WriteFile(StateFilePath(), state)
+		if err != nil {
+			return err
+		}
+		return WriteFile(path, settings)
+	})
+	if err != nil {
+		fmt.Printf("E> Error ERR_MIGRATE_CONFIG: %s\n", err.Error())
+		return
+	}
+	if backup != "" {
+		fmt.Printf("i> Migrated config file from schema version %d to %d, backup of the old file is at: %s\n\n", from, SchemaVersion, backup)
+	}
+}
+
+// schemaVersionOf returns the schema version of the settings file at path,
+// 0 if the file is missing, empty or not valid JSON
+func schemaVersionOf(path string) int {
+	settings, err := ReadFile(path)
+	if err != nil || len(settings) == 0 {
+		return 0
+	}
+	if v, ok := settings["schemaVersion"].(float64); ok {
+		return int(v)
+	}
+	return 1
+}
+
+// migrateV1 moves the update state out of the settings and restores the camel
+// case of the keys lower cased by viper
+func migrateV1(settings, state map[string]interface{}) {
+	for key, value := range settings {
+		for _, stateKey := range stateKeys {
+			if strings.EqualFold(key, stateKey) {
+				state[stateKey] = value
+				delete(settings, key)
+			}
+		}
+	}
+	canonicalizeKeys(settings, Schema)
+	if aliases, ok := settings["aliases"].(map[string]interface{}); ok {
+		for _, alias := range aliases {
+			if m, ok := alias.(map[string]interface{}); ok {
+				canonicalizeKeys(m, aliasSchema)
+			}
+		}
+	}
+}
+
+func canonicalizeKeys(m map[string]interface{}, schema []*KeySpec) {
+	for key, value := range m {
+		spec := lookupKey(schema, key)
+		if spec == nil || spec.Name == key {
+			continue
+		}
+		delete(m, key)
+		m[spec.Name] = value
+	}
+}
+
+func copyFile(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, 0600)
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// configFile is the explicit config file set with the --config flag
+var configFile string
+
+// SetConfigFile sets an explicit path for the config file with the user settings
+func SetConfigFile(path string) {
+	configFile = path
+}
+
+// SettingsFilePath returns the path of the config file with the user settings,
+// by default it is $XDG_CONFIG_HOME/run-flogo-app/config.json
+func SettingsFilePath() string {
+	if configFile != "" {
+		return configFile
+	}
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path
+	}
+	return DefaultSettingsFilePath()
+}
+
+// DefaultSettingsFilePath returns the path of the config file used when none
+// is set with --config or RUN_FLOGO_APP_CONFIG
+func DefaultSettingsFilePath() string {
+	return filepath.Join(userDir("XDG_CONFIG_HOME", os.UserConfigDir, ".config"), AppName, SettingsFileName)
+}
+
+// StateFilePath returns the path of the file where the program keeps its state
+// like the update info, by default it is $XDG_STATE_HOME/run-flogo-app/state.json
+func StateFilePath() string {
+	return filepath.Join(StateDir(), StateFileName)
+}
+
+// StateDir returns the dir for the state of the program, $XDG_STATE_HOME/run-flogo-app by default
+func StateDir() string {
+	return filepath.Join(userDir("XDG_STATE_HOME", userStateDir, filepath.Join(".local", "state")), AppName)
+}
+
+// VersionsDir returns the dir where the previously installed versions of the program are kept
+func VersionsDir() string {
+	return filepath.Join(StateDir(), VersionsDirName)
+}
+
+// LogsDir returns the dir with the logs of the apps run in the background
+func LogsDir() string {
+	return filepath.Join(StateDir(), LogsDirName)
+}
+
+// MkdirPrivate creates the dir only accessible by the user, the permissions of
+// an existing dir created by an older version are restricted too
+func MkdirPrivate(dir string) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dir, 0700)
+}
+
+// CacheDir returns the dir for the files which can be recreated, $XDG_CACHE_HOME/run-flogo-app by default
+func CacheDir() string {
+	return filepath.Join(userDir("XDG_CACHE_HOME", os.UserCacheDir, ".cache"), AppName)
+}
+
+// LegacySettingsFilePath returns the path of the config file used by the older versions
+func LegacySettingsFilePath() string {
+	return filepath.Join(GetUserHomeDir(), ConfigFileName)
+}
+
+// userDir returns the dir from the XDG env variable if set, otherwise the
+// platform specific dir and finally the given dir inside the home dir
+func userDir(env string, platformDir func() (string, error), homeRelative string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	if runtime.GOOS != "linux" {
+		if dir, err := platformDir(); err == nil {
+			return dir
+		}
+	}
+	return filepath.Join(GetUserHomeDir(), homeRelative)
+}
+
+// userStateDir returns the platform specific dir for the state files
+func userStateDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+	}
+	return os.UserConfigDir()
+}
+
+// MigrateLegacyFiles moves the config and state files from the home dir used
+// by the older versions to the XDG locations. It is a no-op once the config
+// file exists in the new location or when an explicit config file is used.
+func MigrateLegacyFiles() {
+	if configFile != "" || os.Getenv(EnvConfigFile) != "" {
+		return
+	}
+	legacy := LegacySettingsFilePath()
+	if _, err := os.Stat(legacy); err != nil {
+		return
+	}
+	settingsPath := SettingsFilePath()
+	if _, err := os.Stat(settingsPath); err == nil {
+		return
+	}
+	moved := false
+	err := withLock(settingsPath, func() error {
+		// Another process may have moved the file while waiting for the lock
+		if _, err := os.Stat(settingsPath); err == nil {
+			return nil
+		}
+		err := moveFile(legacy, settingsPath)
+		if err != nil {
+			return fmt.Errorf("failed to move config file [%s] to [%s]: %s", legacy, settingsPath, err.Error())
+		}
+		legacyState := filepath.Join(GetUserHomeDir(), LegacyStateFileName)
+		if _, err := os.Stat(legacyState); err == nil {
+			moveFile(legacyState, StateFilePath())
+		}
+		moved = true
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("E> Error ERR_MIGRATE_LEGACY: %s\n", err.Error())
+		return
+	}
+	if moved {
+		fmt.Printf("i> Moved config file from [%s] to [%s]\n\n", legacy, settingsPath)
+	}
+}
+
+// moveFile moves the file, when the source can not be removed (e.g. read-only
+// home dir) the file is only copied
+func moveFile(src, dst string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0700)
+	if err != nil {
+		return err
+	}
+	if os.Rename(src, dst) == nil {
+		return nil
+	}
+	err = copyFile(src, dst)
+	if err != nil {
+		return err
+	}
+	os.Remove(src)
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProjectConfigNames are the file names looked up for the per-project config
+var ProjectConfigNames = []string{ConfigFileName, ConfigFileName + ".json", ConfigFileName + ".yaml", ConfigFileName + ".yml"}
+
+// FindProjectConfigs returns the project config files found in dir and its
+// parents, ordered from the outermost to the innermost directory so that the
+// config closest to dir can be merged last. Files in exclude are skipped.
+func FindProjectConfigs(dir string, exclude ...string) []string {
+	skip := map[string]bool{}
+	for _, e := range exclude {
+		if abs, err := filepath.Abs(e); err == nil {
+			skip[abs] = true
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	var found []string
+	for {
+		for _, name := range ProjectConfigNames {
+			path := filepath.Join(dir, name)
+			if skip[path] {
+				continue
+			}
+			if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+				found = append([]string{path}, found...)
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return found
+		}
+		dir = parent
+	}
+}
+
+// ReadProjectConfig reads the project config file at path. Only the project
+// keys of the schema are read, the others are ignored with a warning as a
+// project config comes with the checked out repo. A relative appsDir, or the
+// props file, stats file or workdir of an alias, is resolved against the
+// directory of the file so that it can be checked in.
+func ReadProjectConfig(path string) (*AppConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	v.SetConfigType(configType(path, content))
+	err = v.ReadConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse [%s]: %s", path, err.Error())
+	}
+	for _, key := range ignoredProjectKeys(v.AllKeys()) {
+		fmt.Printf("W> Ignoring the key [%s] of the project config file [%s], it can only be set in the config file\n", key, path)
+	}
+	c := &AppConfig{
+		AppsDir:        v.GetString("appsDir"),
+		AppPattern:     v.GetString("appPattern"),
+		SortBy:         v.GetString("sortBy"),
+		Env:            v.GetStringSlice("env"),
+		ContainerImage: v.GetString("containerImage"),
+	}
+	if c.AppsDir != "" && !filepath.IsAbs(c.AppsDir) {
+		c.AppsDir = filepath.Join(filepath.Dir(path), c.AppsDir)
+	}
+	err = v.UnmarshalKey("aliases", &c.Aliases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aliases from [%s]: %s", path, err.Error())
+	}
+	for _, alias := range c.Aliases {
+		if alias == nil {
+			continue
+		}
+		alias.Props = resolvePath(path, alias.Props)
+		alias.StatsFile = resolvePath(path, alias.StatsFile)
+		alias.Workdir = resolvePath(path, alias.Workdir)
+	}
+	err = v.UnmarshalKey("envProfiles", &c.EnvProfiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env profiles from [%s]: %s", path, err.Error())
+	}
+	return c, nil
+}
+
+// resolvePath resolves a relative path against the dir of the project config file
+func resolvePath(configPath, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(configPath), path)
+}
+
+// ignoredProjectKeys returns the keys of the schema in keys which can not be
+// set in a project config file
+func ignoredProjectKeys(keys []string) []string {
+	var ignored []string
+	seen := map[string]bool{}
+	for _, key := range keys {
+		// viper returns the nested keys like aliases.orders.name
+		spec := LookupKey(strings.SplitN(key, ".", 2)[0])
+		if spec == nil || spec.Project || spec.Name == "schemaVersion" || seen[spec.Name] {
+			continue
+		}
+		seen[spec.Name] = true
+		ignored = append(ignored, spec.Name)
+	}
+	sort.Strings(ignored)
+	return ignored
+}
+
+// IsProjectConfig returns true if the file name is of a project config file
+func IsProjectConfig(path string) bool {
+	name := filepath.Base(path)
+	for _, n := range ProjectConfigNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// configType returns the viper config type from the file extension, or
+// from the content for the files without an extension
+func configType(path string, content []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	if strings.HasPrefix(string(bytes.TrimSpace(content)), "{") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// Merge overlays the project keys set in o on top of c, aliases and env profiles
+// are merged by name and the env of o is appended so that it can override the
+// env of c
+func (c *AppConfig) Merge(o *AppConfig) {
+	if o.AppsDir != "" {
+		c.AppsDir = o.AppsDir
+	}
+	if o.AppPattern != "" {
+		c.AppPattern = o.AppPattern
+	}
+	if o.SortBy != "" {
+		c.SortBy = o.SortBy
+	}
+	if o.ContainerImage != "" {
+		c.ContainerImage = o.ContainerImage
+	}
+	c.Env = append(c.Env, o.Env...)
+	if len(o.Aliases) > 0 && c.Aliases == nil {
+		c.Aliases = map[string]*Alias{}
+	}
+	for name, alias := range o.Aliases {
+		c.Aliases[name] = alias
+	}
+	if len(o.EnvProfiles) > 0 && c.EnvProfiles == nil {
+		c.EnvProfiles = map[string][]string{}
+	}
+	for name, env := range o.EnvProfiles {
+		c.EnvProfiles[name] = env
+	}
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of the values in config file
+const (
+	KindString = "string"
+	KindBool   = "bool"
+	KindList   = "list"
+	KindObject = "object"
+	KindNumber = "number"
+	// KindNumberList is a list of whole numbers
+	KindNumberList = "number list"
+)
+
+// KeySpec describes a key of the config file
+type KeySpec struct {
+	Name        string
+	Kind        string
+	Description string
+	// Settable keys can be changed with the config set command
+	Settable bool
+	// Project keys can also be set in the project config files, the others
+	// change the updates, the downloads or the programs run and are only read
+	// from the config file
+	Project bool
+	// Check validates the value of the key, the value is a string, bool, int, []string or []int as per the kind
+	Check func(v interface{}) error
+}
+
+// Schema lists all the keys supported in the config file
+var Schema = []*KeySpec{
+	{Name: "appsDir", Kind: KindString, Project: true, Settable: true, Description: "Directory containing the flogo apps", Check: checkAppsDir},
+	{Name: "appPattern", Kind: KindString, Project: true, Settable: true, Description: "Regex matching the flogo app file names", Check: checkAppPattern},
+	{Name: "sortBy", Kind: KindString, Project: true, Settable: true, Description: "Strategy to find the latest app: " + strings.Join(SortStrategies, ", ") + ", buildtime is the commit time (vcs.time) embedded in the app", Check: checkSortBy},
+	{Name: "env", Kind: KindList, Project: true, Settable: true, Description: "Environment variables in KEY=VALUE format set for every app", Check: checkEnv},
+	{Name: "aliases", Kind: KindObject, Project: true, Description: "Saved launch recipes, managed with the alias command"},
+	{Name: "envProfiles", Kind: KindObject, Project: true, Description: "Named lists of environment variables in KEY=VALUE format, applied with --env-profile"},
+	{Name: "releaseAPI", Kind: KindString, Settable: true, Description: "Base URL of the GitHub compatible releases API used for the updates", Check: checkReleaseAPI},
+	{Name: "artifactRepo", Kind: KindString, Settable: true, Description: "URL of the artifact repo to fetch the apps from, a directory listing or a JSON index", Check: checkArtifactRepo},
+	{Name: "updateChannel", Kind: KindString, Settable: true, Description: "Release channel used for the updates: " + UpdateChannelStable + " or " + UpdateChannelPrerelease, Check: checkUpdateChannel},
+	{Name: "updateCheckInterval", Kind: KindString, Settable: true, Description: "Minimum duration between the background update checks, e.g. 24h or 0 to check on every run", Check: checkDuration},
+	{Name: "caBundle", Kind: KindString, Settable: true, Description: "PEM file with the CA certificates trusted for the downloads in addition to the system ones", Check: checkCABundle},
+	{Name: "containerImage", Kind: KindString, Project: true, Settable: true, Description: "Image the apps are run in with --container, e.g. the base image they are deployed with (default " + DefaultContainerImage + ")"},
+	{Name: "containerRuntime", Kind: KindString, Settable: true, Description: "Container runtime used with --container, a name or path (default the first found of docker, podman)"},
+	{Name: "keepVersions", Kind: KindNumber, Settable: true, Description: "Number of previously installed versions kept for rollback", Check: checkKeepVersions},
+	{Name: "schemaVersion", Kind: KindNumber, Description: "Internal: version of the config file schema"},
+}
+
+// aliasSchema lists all the keys supported in an alias
+var aliasSchema = []*KeySpec{
+	{Name: "name", Kind: KindString},
+	{Name: "list", Kind: KindBool},
+	{Name: "sortBy", Kind: KindString, Check: checkSortBy},
+	{Name: "logLevel", Kind: KindString, Check: checkLogLevel},
+	{Name: "env", Kind: KindList, Check: checkEnv},
+	{Name: "envProfile", Kind: KindString},
+	{Name: "props", Kind: KindString},
+	{Name: "ports", Kind: KindNumberList, Check: checkPorts},
+	{Name: "detach", Kind: KindBool},
+	{Name: "args", Kind: KindList},
+	{Name: "ready", Kind: KindBool},
+	{Name: "readyHttp", Kind: KindString},
+	{Name: "readyTcp", Kind: KindString},
+	{Name: "readyLog", Kind: KindString, Check: checkRegex},
+	{Name: "readyPort", Kind: KindNumber, Check: checkPort},
+	{Name: "readyTimeout", Kind: KindString, Check: checkDuration},
+	{Name: "stats", Kind: KindBool},
+	{Name: "statsInterval", Kind: KindString, Check: checkDuration},
+	{Name: "statsFile", Kind: KindString},
+	{Name: "statsMetrics", Kind: KindString},
+	{Name: "limitMemory", Kind: KindString},
+	{Name: "limitCpuTime", Kind: KindString, Check: checkDuration},
+	{Name: "limitFiles", Kind: KindNumber},
+	{Name: "limitCpus", Kind: KindString},
+	{Name: "limitPids", Kind: KindNumber},
+	{Name: "cgroup", Kind: KindBool},
+	{Name: "isolate", Kind: KindList},
+	{Name: "workdir", Kind: KindString},
+	{Name: "cleanEnv", Kind: KindBool},
+	{Name: "container", Kind: KindString},
+}
+
+// LookupKey returns the spec of the given key, the lookup is case insensitive
+func LookupKey(key string) *KeySpec {
+	return lookupKey(Schema, key)
+}
+
+func lookupKey(schema []*KeySpec, key string) *KeySpec {
+	for _, k := range schema {
+		if strings.EqualFold(k.Name, key) {
+			return k
+		}
+	}
+	return nil
+}
+
+// SettableKeys returns the names of the keys which can be changed with config set
+func SettableKeys() []string {
+	var keys []string
+	for _, k := range Schema {
+		if k.Settable {
+			keys = append(keys, k.Name)
+		}
+	}
+	return keys
+}
+
+// ParseValue converts a value decoded from JSON into the type of the key and
+// checks it, the bools and numbers can also be given as strings
+func ParseValue(spec *KeySpec, raw interface{}) (interface{}, error) {
+	var value interface{}
+	switch spec.Kind {
+	case KindString:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("key [%s] must be a string", spec.Name)
+		}
+		value = s
+	case KindBool:
+		switch v := raw.(type) {
+		case bool:
+			value = v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("key [%s] must be a bool", spec.Name)
+			}
+			value = b
+		default:
+			return nil, fmt.Errorf("key [%s] must be a bool", spec.Name)
+		}
+	case KindNumber:
+		switch v := raw.(type) {
+		case float64:
+			if v != float64(int(v)) {
+				return nil, fmt.Errorf("key [%s] must be a whole number", spec.Name)
+			}
+			value = int(v)
+		case string:
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("key [%s] must be a whole number", spec.Name)
+			}
+			value = i
+		default:
+			return nil, fmt.Errorf("key [%s] must be a whole number", spec.Name)
+		}
+	case KindList:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("key [%s] must be a list", spec.Name)
+		}
+		list := []string{}
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("key [%s] must be a list of strings", spec.Name)
+			}
+			list = append(list, s)
+		}
+		value = list
+	default:
+		return nil, fmt.Errorf("key [%s] of kind %s can not be set", spec.Name, spec.Kind)
+	}
+	if spec.Check != nil {
+		if err := spec.Check(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func checkAppsDir(v interface{}) error {
+	dir := v.(string)
+	if dir == "" {
+		return nil
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("apps dir [%s] does not exist", dir)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("apps dir [%s] is not a directory", dir)
+	}
+	return nil
+}
+
+func checkAppPattern(v interface{}) error {
+	_, err := regexp.Compile(v.(string))
+	if err != nil {
+		return fmt.Errorf("app pattern does not compile: %s", err.Error())
+	}
+	return nil
+}
+
+func checkSortBy(v interface{}) error {
+	sortBy := v.(string)
+	if sortBy != "" && !IsValidSortBy(sortBy) {
+		return fmt.Errorf("unknown sort strategy [%s], valid strategies are: %s", sortBy, strings.Join(SortStrategies, ", "))
+	}
+	return nil
+}
+
+func checkReleaseAPI(v interface{}) error {
+	s := v.(string)
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("release API [%s] must be an https URL", s)
+	}
+	return nil
+}
+
+func checkArtifactRepo(v interface{}) error {
+	return checkURL("artifact repo", v.(string))
+}
+
+func checkURL(what, s string) error {
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+		return fmt.Errorf("%s [%s] must be an http, https or file URL", what, s)
+	}
+	return nil
+}
+
+func checkUpdateChannel(v interface{}) error {
+	switch v.(string) {
+	case "", UpdateChannelStable, UpdateChannelPrerelease:
+		return nil
+	}
+	return fmt.Errorf("unknown update channel [%s], valid channels are: %s, %s", v, UpdateChannelStable, UpdateChannelPrerelease)
+}
+
+func checkDuration(v interface{}) error {
+	s := v.(string)
+	if s == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration [%s], use a duration like 12h or 30m", s)
+	}
+	if d < 0 {
+		return fmt.Errorf("duration [%s] must not be negative", s)
+	}
+	return nil
+}
+
+func checkCABundle(v interface{}) error {
+	path := v.(string)
+	if path == "" {
+		return nil
+	}
+	rest, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("CA bundle [%s] can not be read", path)
+	}
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return fmt.Errorf("no certificates found in the CA bundle [%s]", path)
+		}
+		if block.Type == "CERTIFICATE" {
+			return nil
+		}
+	}
+}
+
+func checkKeepVersions(v interface{}) error {
+	if v.(int) < 0 {
+		return fmt.Errorf("keep versions [%d] must not be negative", v)
+	}
+	return nil
+}
+
+func checkPorts(v interface{}) error {
+	for _, port := range v.([]int) {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("port [%d] must be between 1 and 65535", port)
+		}
+	}
+	return nil
+}
+
+func checkPort(v interface{}) error {
+	return checkPorts([]int{v.(int)})
+}
+
+func checkRegex(v interface{}) error {
+	if _, err := regexp.Compile(v.(string)); err != nil {
+		return fmt.Errorf("invalid regex [%s]: %s", v, err.Error())
+	}
+	return nil
+}
+
+func checkLogLevel(v interface{}) error {
+	switch v.(string) {
+	case "", LogLevelInfo, LogLevelDebug, LogLevelTrace:
+		return nil
+	}
+	return fmt.Errorf("unknown log level [%s], valid log levels are: %s, %s, %s", v, LogLevelInfo, LogLevelDebug, LogLevelTrace)
+}
+
+func checkEnv(v interface{}) error {
+	return CheckEnv(v.([]string))
+}
+
+// CheckEnv returns an error if a variable of env is not in KEY=VALUE format
+func CheckEnv(env []string) error {
+	for _, e := range env {
+		if !strings.Contains(e, "=") || strings.HasPrefix(e, "=") {
+			return fmt.Errorf("environment variable [%s] must be in KEY=VALUE format", e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ReadFile reads the JSON file at path into a map, a missing file is read as an empty map
+func ReadFile(path string) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse [%s]: %s", path, err.Error())
+	}
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+	return m, nil
+}
+
+// WriteFile writes the map into the JSON file at path atomically. The file is
+// only readable by the user as it can have the env of the apps.
+func WriteFile(path string, m map[string]interface{}) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(b, '\n'), 0600)
+}
+
+// Update reads the JSON file at path, applies fn on it and writes it back
+// while holding the lock on the file, so that the concurrent updates from
+// this and other processes are not lost
+func Update(path string, fn func(m map[string]interface{})) error {
+	return withLock(path, func() error {
+		m, err := ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fn(m)
+		return WriteFile(path, m)
+	})
+}
+
+// UpdateSettings applies fn on the settings file and stamps the current schema
+// version, a higher version written by a newer release is kept
+func UpdateSettings(fn func(m map[string]interface{})) error {
+	return Update(SettingsFilePath(), func(m map[string]interface{}) {
+		fn(m)
+		if cur, _ := m["schemaVersion"].(float64); cur < SchemaVersion {
+			m["schemaVersion"] = SchemaVersion
+		}
+	})
+}
+
+// UpdateState applies fn on the state file
+func UpdateState(fn func(m map[string]interface{})) error {
+	return Update(StateFilePath(), fn)
+}
@@ -0,0 +1,374 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an error or warning found while validating a config file
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+	Warning bool
+}
+
+func (p *Problem) String() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	if p.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, level, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.File, level, p.Message)
+}
+
+// HasErrors returns true if any of the problems is not a warning
+func HasErrors(problems []*Problem) bool {
+	for _, p := range problems {
+		if !p.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// node is a parsed value of the config file along with its line number
+type node struct {
+	line   int
+	kind   string
+	value  interface{}
+	keys   []string
+	fields map[string]*node
+	items  []*node
+}
+
+// ValidateFile validates the syntax, schema and values of the config file at path
+func ValidateFile(path string) []*Problem {
+	return validateFile(path, false)
+}
+
+// ValidateProjectFile validates the project config file at path like
+// ValidateFile, and warns about the keys which are ignored in a project config
+func ValidateProjectFile(path string) []*Problem {
+	return validateFile(path, true)
+}
+
+func validateFile(path string, project bool) []*Problem {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []*Problem{{File: path, Message: err.Error()}}
+	}
+	var root *node
+	if configType(path, content) == "json" {
+		root, err = parseJSON(content)
+	} else {
+		root, err = parseYAML(content)
+	}
+	if err != nil {
+		line := 0
+		var pe *parseError
+		if errors.As(err, &pe) {
+			line = pe.line
+		}
+		return []*Problem{{File: path, Line: line, Message: err.Error()}}
+	}
+	v := &validator{file: path, project: project}
+	if root == nil {
+		return nil
+	}
+	if root.kind != KindObject {
+		v.errorf(root.line, "config must be an object, found %s", root.kind)
+		return v.problems
+	}
+	v.object(root, Schema, "")
+	if aliases, ok := root.field("aliases"); ok && aliases.kind == KindObject {
+		for _, name := range aliases.keys {
+			alias := aliases.fields[name]
+			if alias.kind != KindObject {
+				v.errorf(alias.line, "alias [%s] must be an object, found %s", name, alias.kind)
+				continue
+			}
+			v.object(alias, aliasSchema, "alias ["+name+"] ")
+		}
+	}
+	if profiles, ok := root.field("envProfiles"); ok && profiles.kind == KindObject {
+		for _, name := range profiles.keys {
+			profile := profiles.fields[name]
+			env, ok := profile.typed(KindList)
+			if !ok {
+				v.errorf(profile.line, "env profile [%s] must be a list of strings, found %s", name, profile.kind)
+				continue
+			}
+			if err := checkEnv(env); err != nil {
+				v.errorf(profile.line, "env profile [%s]: %s", name, err.Error())
+			}
+		}
+	}
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		return v.problems[i].Line < v.problems[j].Line
+	})
+	return v.problems
+}
+
+type validator struct {
+	file     string
+	project  bool
+	problems []*Problem
+}
+
+func (v *validator) errorf(line int, format string, args ...interface{}) {
+	v.problems = append(v.problems, &Problem{File: v.file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(line int, format string, args ...interface{}) {
+	v.problems = append(v.problems, &Problem{File: v.file, Line: line, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (v *validator) object(n *node, schema []*KeySpec, prefix string) {
+	for _, key := range n.keys {
+		value := n.fields[key]
+		spec := lookupKey(schema, key)
+		if spec == nil {
+			v.warnf(value.line, "%sunknown key [%s]", prefix, key)
+			continue
+		}
+		if v.project && prefix == "" && !spec.Project && spec.Name != "schemaVersion" {
+			v.warnf(value.line, "key [%s] is ignored in a project config file, it can only be set in the config file", key)
+			continue
+		}
+		if value.kind == "null" {
+			continue
+		}
+		typed, ok := value.typed(spec.Kind)
+		if !ok && spec.Kind == KindNumber {
+			v.errorf(value.line, "%skey [%s] must be a whole number, found %v", prefix, key, value.value)
+			continue
+		}
+		if !ok && spec.Kind == KindNumberList {
+			v.errorf(value.line, "%skey [%s] must be a list of whole numbers", prefix, key)
+			continue
+		}
+		if !ok {
+			v.errorf(value.line, "%skey [%s] must be a %s, found %s", prefix, key, spec.Kind, value.kind)
+			continue
+		}
+		if spec.Check == nil {
+			continue
+		}
+		if err := spec.Check(typed); err != nil {
+			v.errorf(value.line, "%skey [%s]: %s", prefix, key, err.Error())
+		}
+	}
+}
+
+// field returns the field of an object node, the lookup is case insensitive
+// as viper writes the keys in lower case
+func (n *node) field(key string) (*node, bool) {
+	for _, k := range n.keys {
+		if strings.EqualFold(k, key) {
+			return n.fields[k], true
+		}
+	}
+	return nil, false
+}
+
+// typed converts the node into the go value for the given kind
+func (n *node) typed(kind string) (interface{}, bool) {
+	switch kind {
+	case KindString, KindBool:
+		return n.value, n.kind == kind
+	case KindList:
+		if n.kind != KindList {
+			return nil, false
+		}
+		var list []string
+		for _, item := range n.items {
+			if item.kind != KindString {
+				return nil, false
+			}
+			list = append(list, item.value.(string))
+		}
+		return list, true
+	case KindNumberList:
+		if n.kind != KindList {
+			return nil, false
+		}
+		var list []int
+		for _, item := range n.items {
+			i, ok := item.typed(KindNumber)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, i.(int))
+		}
+		return list, true
+	case KindNumber:
+		if n.kind != KindNumber {
+			return nil, false
+		}
+		i, err := strconv.Atoi(fmt.Sprint(n.value))
+		return i, err == nil
+	}
+	return nil, n.kind == kind
+}
+
+type parseError struct {
+	line int
+	err  error
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
+
+func parseJSON(content []byte) (*node, error) {
+	d := json.NewDecoder(bytes.NewReader(content))
+	d.UseNumber()
+	lineAt := func(offset int64) int {
+		if offset > int64(len(content)) {
+			offset = int64(len(content))
+		}
+		return bytes.Count(content[:offset], []byte("\n")) + 1
+	}
+	wrap := func(err error) error {
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			return &parseError{line: lineAt(se.Offset), err: fmt.Errorf("invalid JSON: %s", se.Error())}
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return &parseError{line: lineAt(d.InputOffset()), err: fmt.Errorf("invalid JSON: %s", err.Error())}
+	}
+	var parse func() (*node, error)
+	parse = func() (*node, error) {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, wrap(err)
+		}
+		n := &node{line: lineAt(d.InputOffset())}
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '{' {
+				n.kind, n.fields = KindObject, map[string]*node{}
+				for d.More() {
+					keyTok, err := d.Token()
+					if err != nil {
+						return nil, wrap(err)
+					}
+					key := keyTok.(string)
+					keyLine := lineAt(d.InputOffset())
+					value, err := parse()
+					if err != nil {
+						return nil, err
+					}
+					value.line = keyLine
+					if _, dup := n.fields[key]; dup {
+						return nil, &parseError{line: keyLine, err: fmt.Errorf("duplicate key [%s]", key)}
+					}
+					n.keys = append(n.keys, key)
+					n.fields[key] = value
+				}
+			} else {
+				n.kind = KindList
+				for d.More() {
+					item, err := parse()
+					if err != nil {
+						return nil, err
+					}
+					n.items = append(n.items, item)
+				}
+			}
+			// Consume the closing delimiter
+			if _, err := d.Token(); err != nil {
+				return nil, wrap(err)
+			}
+		case string:
+			n.kind, n.value = KindString, t
+		case bool:
+			n.kind, n.value = KindBool, t
+		case json.Number:
+			n.kind, n.value = KindNumber, t
+		case nil:
+			n.kind = "null"
+		}
+		return n, nil
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	root, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, &parseError{line: lineAt(d.InputOffset()), err: errors.New("invalid JSON: unexpected content after the top level object")}
+	}
+	return root, nil
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+func parseYAML(content []byte) (*node, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(content, &doc)
+	if err != nil {
+		pe := &parseError{err: fmt.Errorf("invalid YAML: %s", err.Error())}
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			pe.line, _ = strconv.Atoi(m[1])
+		}
+		return nil, pe
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	var convert func(y *yaml.Node) *node
+	convert = func(y *yaml.Node) *node {
+		n := &node{line: y.Line}
+		switch y.Kind {
+		case yaml.MappingNode:
+			n.kind, n.fields = KindObject, map[string]*node{}
+			for i := 0; i+1 < len(y.Content); i += 2 {
+				key := y.Content[i].Value
+				value := convert(y.Content[i+1])
+				value.line = y.Content[i].Line
+				n.keys = append(n.keys, key)
+				n.fields[key] = value
+			}
+		case yaml.SequenceNode:
+			n.kind = KindList
+			for _, item := range y.Content {
+				n.items = append(n.items, convert(item))
+			}
+		case yaml.AliasNode:
+			return convert(y.Alias)
+		default:
+			switch y.ShortTag() {
+			case "!!bool":
+				var b bool
+				y.Decode(&b)
+				n.kind, n.value = KindBool, b
+			case "!!int", "!!float":
+				n.kind, n.value = KindNumber, y.Value
+			case "!!null":
+				n.kind = "null"
+			default:
+				n.kind, n.value = KindString, y.Value
+			}
+		}
+		return n
+	}
+	return convert(doc.Content[0]), nil
+}
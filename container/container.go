@@ -0,0 +1,133 @@
+// Package container runs the apps in a container with docker or podman, like
+// they are deployed, to catch the issues of the image like a missing libc or
+// missing CA certificates before pushing them
+package container
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runtimes are the supported container runtimes, the first one found is used
+var Runtimes = []string{"docker", "podman"}
+
+// appDir is the dir of the app in the container
+const appDir = "/app"
+
+// Runtime is a container runtime CLI
+type Runtime struct {
+	Name string
+	Path string
+}
+
+// Options holds how to run the app in the container
+type Options struct {
+	Image string
+	// Name is the name of the container
+	Name string
+	// Ports are published on the same port of the host
+	Ports []int
+	// Env are the names of the variables passed from the environment of the
+	// runtime to the container
+	Env []string
+	// Workdir is a host dir mounted as the working directory of the app
+	Workdir string
+	// The limits of the container, ignored if zero
+	Memory    int64
+	CPUs      float64
+	PIDs      int
+	OpenFiles uint64
+	CPUTime   time.Duration
+}
+
+// Detect returns the runtime with the name or path, or the first runtime of
+// Runtimes found in the PATH if name is empty
+func Detect(name string) (*Runtime, error) {
+	candidates := Runtimes
+	if name != "" {
+		candidates = []string{name}
+	}
+	for _, c := range candidates {
+		if path, err := exec.LookPath(c); err == nil {
+			return &Runtime{Name: strings.TrimSuffix(filepath.Base(c), ".exe"), Path: path}, nil
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("container runtime [%s] not found", name)
+	}
+	return nil, fmt.Errorf("no container runtime found, install one of: %s", strings.Join(Runtimes, ", "))
+}
+
+// Command returns the command running the app at path in a container, which
+// is removed once it exits. The env is the environment of the runtime, which
+// holds the values of the variables passed to the container.
+func (r *Runtime) Command(path string, args []string, env []string, o *Options) *exec.Cmd {
+	app := appDir + "/" + filepath.Base(path)
+	cmdArgs := []string{"run", "--rm", "--name", o.Name,
+		"--volume", path + ":" + app + ":ro",
+		"--workdir", appDir,
+	}
+	if o.Workdir != "" {
+		cmdArgs = append(cmdArgs, "--volume", o.Workdir+":/work", "--workdir", "/work")
+	}
+	for _, port := range o.Ports {
+		p := strconv.Itoa(port)
+		cmdArgs = append(cmdArgs, "--publish", p+":"+p)
+	}
+	// The values are read from the environment of the runtime, so that they
+	// are not visible in the command line
+	for _, name := range o.Env {
+		cmdArgs = append(cmdArgs, "--env", name)
+	}
+	if o.Memory > 0 {
+		cmdArgs = append(cmdArgs, "--memory", strconv.FormatInt(o.Memory, 10))
+	}
+	if o.CPUs > 0 {
+		cmdArgs = append(cmdArgs, "--cpus", strconv.FormatFloat(o.CPUs, 'f', -1, 64))
+	}
+	if o.PIDs > 0 {
+		cmdArgs = append(cmdArgs, "--pids-limit", strconv.Itoa(o.PIDs))
+	}
+	if o.OpenFiles > 0 {
+		cmdArgs = append(cmdArgs, "--ulimit", fmt.Sprintf("nofile=%d:%d", o.OpenFiles, o.OpenFiles))
+	}
+	if o.CPUTime > 0 {
+		secs := int64(math.Ceil(o.CPUTime.Seconds()))
+		cmdArgs = append(cmdArgs, "--ulimit", fmt.Sprintf("cpu=%d:%d", secs, secs))
+	}
+	cmdArgs = append(cmdArgs, o.Image, app)
+	cmd := exec.Command(r.Path, append(cmdArgs, args...)...)
+	cmd.Env = env
+	return cmd
+}
+
+// Remove removes the container with the name, even if it is running
+func (r *Runtime) Remove(name string) error {
+	return exec.Command(r.Path, "rm", "--force", name).Run()
+}
+
+// CheckLinuxBinary returns an error if the file at path is not a linux
+// executable, which is the only kind a container can run
+func CheckLinuxBinary(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := f.Read(magic); err != nil {
+		return err
+	}
+	if !bytes.Equal(magic, []byte("\x7fELF")) {
+		return errors.New("the app is not a linux executable, the container needs the linux build of the app")
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+// Package deploy generates the deployment files of an app, a Dockerfile,
+// kubernetes manifests or a docker compose service, from its descriptor and
+// the environment it is run with locally
+package deploy
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+)
+
+// Formats are the supported formats of the deployment files
+var Formats = []string{"docker", "k8s", "compose"}
+
+// fileNames are the names of the files written for the formats
+var fileNames = map[string]string{
+	"docker":  "Dockerfile",
+	"k8s":     "k8s.yaml",
+	"compose": "compose.yaml",
+}
+
+// AppDir is the dir of the app in the image
+const AppDir = "/app"
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"quote":  quote,
+	"docker": dockerQuote,
+	"json":   jsonArray,
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// platformSuffix matches the platform suffix of the app binaries, e.g.
+// -linux_amd64, which is not part of the app name
+var platformSuffix = regexp.MustCompile(`(?i)[-_](linux|darwin|windows)[-_][a-z0-9]+.*$`)
+
+// Model is the app as described in the deployment files
+type Model struct {
+	// Name is the name of the app usable as a kubernetes resource name
+	Name    string
+	AppName string
+	Version string
+	// Binary is the file name of the app
+	Binary string
+	Image  string
+	// BaseImage is the image the Dockerfile builds on
+	BaseImage  string
+	Ports      []*Port
+	Env        []*EnvVar
+	Properties []*Property
+	Args       []string
+	// Generator is the program and version which generated the files
+	Generator string
+}
+
+// Port is a port the app listens on
+type Port struct {
+	// Name is the name of the port in kubernetes
+	Name    string
+	Port    int
+	Trigger string
+}
+
+// EnvVar is a variable set for the app
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// Property is an app property with the value it has with the env of the app
+type Property struct {
+	Name  string
+	Type  string
+	Value string
+	// Env is the variable overriding the property with FLOGO_APP_PROPS_ENV=auto
+	Env string
+}
+
+// New returns the model of the app at path, with its descriptor if found,
+// its trigger ports, the variables set for it and its args
+func New(path string, d *flogo.Descriptor, tps []*flogo.TriggerPort, env, args []string) *Model {
+	m := &Model{
+		Binary:  filepath.Base(path),
+		Version: "latest",
+		Args:    args,
+	}
+	m.AppName = platformSuffix.ReplaceAllString(strings.TrimSuffix(m.Binary, filepath.Ext(m.Binary)), "")
+	if d != nil {
+		if d.Name != "" {
+			m.AppName = d.Name
+		}
+		if d.Version != "" {
+			m.Version = d.Version
+		}
+		for _, p := range d.Properties {
+			m.Properties = append(m.Properties, &Property{
+				Name:  p.Name,
+				Type:  p.Type,
+				Value: format(d.PropertyValue(p.Name, env)),
+				Env:   strings.ToUpper(strings.ReplaceAll(p.Name, ".", "_")),
+			})
+		}
+	}
+	m.Name = resourceName(m.AppName)
+	m.Image = m.Name + ":" + m.Version
+	names := map[string]bool{}
+	seen := map[int]bool{}
+	for _, tp := range tps {
+		if seen[tp.Port] {
+			continue
+		}
+		seen[tp.Port] = true
+		p := &Port{Port: tp.Port, Trigger: tp.TriggerID, Name: portName(tp.TriggerID)}
+		if p.Name == "" || names[p.Name] {
+			p.Name = "port-" + strconv.Itoa(tp.Port)
+		}
+		names[p.Name] = true
+		m.Ports = append(m.Ports, p)
+	}
+	// The last value of a variable wins, like in the environment of a process
+	index := map[string]*EnvVar{}
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if v, ok := index[kv[0]]; ok {
+			v.Value = kv[1]
+			continue
+		}
+		index[kv[0]] = &EnvVar{Name: kv[0], Value: kv[1]}
+		m.Env = append(m.Env, index[kv[0]])
+	}
+	sort.SliceStable(m.Env, func(i, j int) bool { return m.Env[i].Name < m.Env[j].Name })
+	return m
+}
+
+// FileName returns the name of the file written for the format
+func FileName(format string) string {
+	return fileNames[format]
+}
+
+// Render writes the deployment file of the format for the app to w
+func (m *Model) Render(w io.Writer, format string) error {
+	t := templates.Lookup(format + ".tmpl")
+	if t == nil {
+		return fmt.Errorf("unknown format [%s], valid formats are: %s", format, strings.Join(Formats, ", "))
+	}
+	return t.Execute(w, m)
+}
+
+// Dir returns the dir of the app in the image
+func (m *Model) Dir() string {
+	return AppDir
+}
+
+// Path returns the path of the app in the image
+func (m *Model) Path() string {
+	return AppDir + "/" + m.Binary
+}
+
+// Alpine returns true if the base image is alpine, which needs the CA
+// certificates installed
+func (m *Model) Alpine() bool {
+	return strings.HasPrefix(m.BaseImage, "alpine") || strings.Contains(m.BaseImage, "/alpine")
+}
+
+// resourceName returns name as a DNS label, which kubernetes requires for the
+// names of the resources
+func resourceName(name string) string {
+	n := dnsLabel(name, 63)
+	if n == "" {
+		return "flogo-app"
+	}
+	return n
+}
+
+// portName returns the trigger id as a port name, which kubernetes limits to
+// 15 chars with at least a letter, or an empty string if it has no letter
+func portName(id string) string {
+	n := dnsLabel(id, 15)
+	if strings.IndexFunc(n, func(r rune) bool { return r >= 'a' && r <= 'z' }) < 0 {
+		return ""
+	}
+	return n
+}
+
+func dnsLabel(s string, max int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	n := b.String()
+	if len(n) > max {
+		n = n[:max]
+	}
+	return strings.Trim(n, "-")
+}
+
+// format returns the value of a property as set in the environment, on a
+// single line as it is shown in comments
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// quote returns s as a double quoted string, valid in YAML and in the JSON
+// form of the Dockerfile instructions
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// dockerQuote returns s quoted for an ENV instruction, which expands the
+// variables
+func dockerQuote(s string) string {
+	return strings.ReplaceAll(quote(s), "$", `\$`)
+}
+
+func jsonArray(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = quote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,123 @@
+// Package download fetches files over HTTP for the updates and the apps, it
+// supports resuming the downloads, proxies and custom CA bundles
+package download
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+)
+
+// ErrOffline is returned for the network requests when the program runs offline
+var ErrOffline = errors.New("network access is disabled with --offline or " + config.EnvOffline)
+
+var (
+	// offline disables all the network requests, file:// URLs of the
+	// LocalClient still work
+	offline  bool
+	caBundle string
+
+	clientOnce  sync.Once
+	client      *http.Client
+	localClient *http.Client
+)
+
+// SetOffline enables or disables the network access of the program
+func SetOffline(o bool) {
+	offline = o
+}
+
+// IsOffline returns true if the network access is disabled
+func IsOffline() bool {
+	return offline
+}
+
+// SetCABundle sets a PEM file with the CA certificates trusted in addition
+// to the system ones, it must be called before the first request
+func SetCABundle(path string) {
+	caBundle = path
+}
+
+// Client returns the client used for all the requests. It uses the proxy
+// from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY env variables and only
+// supports http and https, so that a URL returned by a server can not read
+// the local files. It has no overall timeout as it is used for large
+// downloads as well, the requests are bounded with the timeouts of the
+// transport and their context.
+func Client() *http.Client {
+	clientOnce.Do(newClients)
+	return client
+}
+
+// LocalClient returns the client which also supports file:// URLs, so that
+// the files can be served from a local dir. It must only be used for the
+// URLs set by the user, like an artifact repo in a local dir.
+func LocalClient() *http.Client {
+	clientOnce.Do(newClients)
+	return localClient
+}
+
+func newClients() {
+	t := newTransport()
+	client = &http.Client{Transport: &offlineTransport{next: t}}
+	local := t.Clone()
+	local.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	localClient = &http.Client{Transport: &offlineTransport{next: local}}
+}
+
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	t.DialContext = (&net.Dialer{
+		Timeout:   config.HTTPTimeout * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	t.TLSHandshakeTimeout = config.HTTPTimeout * time.Second
+	t.ResponseHeaderTimeout = config.HTTPTimeout * time.Second
+	if caBundle != "" {
+		pool, err := loadCABundle(caBundle)
+		if err != nil {
+			fmt.Printf("W> Unable to use the CA bundle, only the system CAs are trusted: %s\n", err.Error())
+		} else {
+			t.TLSClientConfig = &tls.Config{RootCAs: pool}
+		}
+	}
+	return t
+}
+
+// loadCABundle returns the system CAs along with the ones in the PEM file at path
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in [%s]", path)
+	}
+	return pool, nil
+}
+
+// offlineTransport fails the requests to the network when running offline,
+// the file:// URLs are only served by the transport of the LocalClient
+type offlineTransport struct {
+	next http.RoundTripper
+}
+
+func (t *offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if offline && req.URL.Scheme != "file" {
+		return nil, ErrOffline
+	}
+	return t.next.RoundTrip(req)
+}
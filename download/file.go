@@ -0,0 +1,224 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// partSuffix is added to the file being downloaded until it is complete
+const partSuffix = ".part"
+
+// IsPartial returns true if the file name is of an incomplete download
+func IsPartial(name string) bool {
+	return strings.HasSuffix(name, partSuffix) || strings.HasSuffix(name, partSuffix+".json")
+}
+
+// Options of a download
+type Options struct {
+	// Quiet disables the progress reporting
+	Quiet bool
+	// Local allows the file:// URLs, it must only be set for the URLs set by
+	// the user and not for the ones returned by a server
+	Local bool
+	// SHA256 is the expected checksum, the download is deleted instead of
+	// being moved into place if it differs
+	SHA256 string
+}
+
+// ChecksumError is returned when the download does not have the expected SHA-256
+type ChecksumError struct {
+	Name     string
+	Actual   string
+	Expected string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("SHA-256 of [%s] is %s but %s is expected", e.Name, e.Actual, e.Expected)
+}
+
+// partMeta is saved next to the partial file, it identifies the version of
+// the file being downloaded so that only the same version is resumed
+type partMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// validator returns the value of the If-Range header to resume the download
+func (m *partMeta) validator() string {
+	// Weak ETags can not be used with If-Range
+	if m.ETag != "" && !strings.HasPrefix(m.ETag, "W/") {
+		return m.ETag
+	}
+	return m.LastModified
+}
+
+// File downloads url into dst. The data is written to dst.part which is
+// renamed to dst only when the download is complete and has the expected
+// checksum, so an existing dst is only replaced by a good download. An
+// interrupted download is resumed from where it stopped if the server
+// supports range requests.
+func File(ctx context.Context, url, dst string, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	return download(ctx, url, dst, opts, true)
+}
+
+func download(ctx context.Context, url, dst string, opts *Options, resume bool) error {
+	part := dst + partSuffix
+	metaPath := part + ".json"
+	var offset int64
+	meta := readPartMeta(metaPath)
+	if resume && meta != nil && meta.URL == url && meta.validator() != "" {
+		if fi, err := os.Stat(part); err == nil {
+			offset = fi.Size()
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", meta.validator())
+	}
+	c := Client()
+	if opts.Local {
+		c = LocalClient()
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	total := int64(-1)
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			resp.Body.Close()
+			return download(ctx, url, dst, opts, false)
+		}
+		flags |= os.O_APPEND
+		total = size
+	case http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is complete if it has the size of the file
+		if _, size, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && size == offset {
+			return finish(part, metaPath, dst, opts)
+		}
+		resp.Body.Close()
+		return download(ctx, url, dst, opts, false)
+	default:
+		return fmt.Errorf("bad status from [%s]: %s", url, resp.Status)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		meta = &partMeta{URL: url, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+		if err := writePartMeta(metaPath, meta); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return err
+	}
+	p := newProgress(filepath.Base(dst), offset, total, opts.Quiet)
+	n, err := io.Copy(f, io.TeeReader(resp.Body, p))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	p.finish(err == nil)
+	if err != nil {
+		if meta.validator() != "" {
+			return fmt.Errorf("download interrupted after %s, run it again to resume: %w", formatBytes(offset+n), err)
+		}
+		return fmt.Errorf("download interrupted after %s: %w", formatBytes(offset+n), err)
+	}
+	if total >= 0 && offset+n != total {
+		return fmt.Errorf("download incomplete, got %s of %s", formatBytes(offset+n), formatBytes(total))
+	}
+	return finish(part, metaPath, dst, opts)
+}
+
+// finish moves the complete partial file into place if it has the expected checksum
+func finish(part, metaPath, dst string, opts *Options) error {
+	if opts.SHA256 != "" {
+		sum, err := SHA256File(part)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sum, opts.SHA256) {
+			os.Remove(part)
+			os.Remove(metaPath)
+			return &ChecksumError{Name: filepath.Base(dst), Actual: sum, Expected: opts.SHA256}
+		}
+	}
+	if err := os.Rename(part, dst); err != nil {
+		return err
+	}
+	os.Remove(metaPath)
+	return nil
+}
+
+func readPartMeta(path string) *partMeta {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	meta := new(partMeta)
+	if json.Unmarshal(b, meta) != nil {
+		return nil
+	}
+	return meta
+}
+
+func writePartMeta(path string, meta *partMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// parseContentRange parses a Content-Range header like "bytes 100-199/1000"
+// or "bytes */1000", it returns -1 as start for the latter
+func parseContentRange(s string) (start, size int64, ok bool) {
+	s = strings.TrimPrefix(s, "bytes ")
+	i := strings.LastIndex(s, "/")
+	if i < 0 {
+		return 0, 0, false
+	}
+	size, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if s[:i] == "*" {
+		return -1, size, true
+	}
+	r := strings.SplitN(s[:i], "-", 2)
+	start, err = strconv.ParseInt(r[0], 10, 64)
+	if err != nil || len(r) != 2 {
+		return 0, 0, false
+	}
+	return start, size, true
+}
@@ -0,0 +1,104 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressInterval is the minimum duration between the updates of the progress bar
+const progressInterval = 200 * time.Millisecond
+
+// progress reports the progress of a download, the bar is shown only when
+// the output is a terminal and a summary is printed at the end otherwise
+type progress struct {
+	name    string
+	done    int64
+	total   int64
+	resumed int64
+	start   time.Time
+	last    time.Time
+	quiet   bool
+	bar     bool
+}
+
+func newProgress(name string, offset, total int64, quiet bool) *progress {
+	p := &progress{name: name, done: offset, total: total, resumed: offset, start: time.Now(), quiet: quiet}
+	if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		p.bar = !quiet
+	}
+	if offset > 0 && !quiet {
+		fmt.Printf("#> Resuming the download of %s from %s\n", name, formatBytes(offset))
+	}
+	return p
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if p.bar && time.Since(p.last) >= progressInterval {
+		p.last = time.Now()
+		p.print()
+	}
+	return len(b), nil
+}
+
+// rate returns the download speed in bytes per second
+func (p *progress) rate() float64 {
+	elapsed := time.Since(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.done-p.resumed) / elapsed
+}
+
+func (p *progress) print() {
+	rate := p.rate()
+	if p.total <= 0 {
+		fmt.Printf("\r#> %s  %s  %s/s   ", p.name, formatBytes(p.done), formatBytes(int64(rate)))
+		return
+	}
+	const width = 30
+	filled := int(float64(width) * float64(p.done) / float64(p.total))
+	if filled > width {
+		filled = width
+	}
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	if filled < width {
+		bar = strings.Repeat("=", filled) + ">" + strings.Repeat(" ", width-filled-1)
+	}
+	eta := "--"
+	if rate > 0 {
+		eta = (time.Duration(float64(p.total-p.done)/rate) * time.Second).Round(time.Second).String()
+	}
+	fmt.Printf("\r#> %s [%s] %3d%%  %s / %s  %s/s  ETA %s   ", p.name, bar, p.done*100/p.total,
+		formatBytes(p.done), formatBytes(p.total), formatBytes(int64(rate)), eta)
+}
+
+// finish prints the final state of the download
+func (p *progress) finish(ok bool) {
+	if p.quiet {
+		return
+	}
+	if p.bar {
+		p.print()
+		fmt.Println()
+	}
+	if ok {
+		fmt.Printf("#> Downloaded %s (%s in %s)\n", p.name, formatBytes(p.done), time.Since(p.start).Round(time.Millisecond))
+	}
+}
+
+// formatBytes returns the size in a human readable format, e.g. 1.5 MB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,16 @@
+package files
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// createTime returns the birth time of the file
+func createTime(path string, f fs.FileInfo) time.Time {
+	st, ok := f.Sys().(*syscall.Stat_t)
+	if !ok {
+		return f.ModTime()
+	}
+	return time.Unix(st.Birthtimespec.Unix())
+}
@@ -0,0 +1,22 @@
+package files
+
+import (
+	"io/fs"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// createTime returns the birth time of the file if the filesystem supports it,
+// otherwise the inode change time
+func createTime(path string, f fs.FileInfo) time.Time {
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, unix.AT_SYMLINK_NOFOLLOW, unix.STATX_BTIME|unix.STATX_CTIME, &stx)
+	if err != nil {
+		return f.ModTime()
+	}
+	if stx.Mask&unix.STATX_BTIME != 0 {
+		return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+	}
+	return time.Unix(stx.Ctime.Sec, int64(stx.Ctime.Nsec))
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package files
+
+import (
+	"io/fs"
+	"time"
+)
+
+// createTime falls back to ModTime on platforms without a creation time
+func createTime(path string, f fs.FileInfo) time.Time {
+	return f.ModTime()
+}
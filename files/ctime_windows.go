@@ -0,0 +1,16 @@
+package files
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// createTime returns the creation time of the file
+func createTime(path string, f fs.FileInfo) time.Time {
+	attr, ok := f.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return f.ModTime()
+	}
+	return time.Unix(0, attr.CreationTime.Nanoseconds())
+}
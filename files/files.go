@@ -8,36 +8,32 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
-	"sort"
 	"strings"
 
 	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
 	"github.com/abhijitWakchaure/run-flogo-app/software"
 )
 
-// FindLatestApp will return the latest flogo app name
-func FindLatestApp(dir, pattern string) string {
+// FindLatestApp will return the latest flogo app name as per the sort strategy
+func FindLatestApp(dir, pattern, sortBy string) string {
 	fmt.Printf("#> Finding latest app inside apps dir [%s]...\n", dir)
-	files := listAndSort(dir)
-	validApp := regexp.MustCompile(pattern)
-	for _, f := range files {
-		if !f.IsDir() && validApp.MatchString(f.Name()) {
-			return filepath.Join(dir, f.Name())
-		}
+	files := listAndSort(dir, pattern, sortBy)
+	if len(files) > 0 {
+		return filepath.Join(dir, files[0].Name())
 	}
 	fmt.Println("#> No flogo apps found in " + dir)
 	return ""
 }
 
 // FindAppsWithName will return the list of matching flogo apps
-func FindAppsWithName(dir, pattern, name string) []string {
+func FindAppsWithName(dir, pattern, name, sortBy string) []string {
 	fmt.Printf("#> Searching apps with name containing '%s' inside apps dir [%s]...\n", name, dir)
 	var apps []string
 	name = strings.ToLower(name)
-	files := listAndSort(dir)
-	validApp := regexp.MustCompile(pattern)
+	files := listAndSort(dir, pattern, sortBy)
 	for _, f := range files {
-		if !f.IsDir() && validApp.MatchString(f.Name()) && strings.Contains(strings.ToLower(f.Name()), name) {
+		if strings.Contains(strings.ToLower(f.Name()), name) {
 			apps = append(apps, filepath.Join(dir, f.Name()))
 		}
 	}
@@ -45,35 +41,29 @@ func FindAppsWithName(dir, pattern, name string) []string {
 }
 
 // ListLastNApps will return the list of last 'N' flogo apps
-func ListLastNApps(dir, pattern string, n int) []string {
+func ListLastNApps(dir, pattern, sortBy string, n int) []string {
 	fmt.Printf("#> Listing last %d apps inside apps dir [%s]...\n", n, dir)
-	files := listAndSort(dir)
+	files := listAndSort(dir, pattern, sortBy)
 	var apps []string
-	validApp := regexp.MustCompile(pattern)
 	for _, f := range files {
-		if !f.IsDir() && validApp.MatchString(f.Name()) {
-			apps = append(apps, filepath.Join(dir, f.Name()))
-			if len(apps) == n {
-				return apps
-			}
+		apps = append(apps, filepath.Join(dir, f.Name()))
+		if len(apps) == n {
+			return apps
 		}
 	}
 	return apps
 }
 
 // DeleteApps will delete all the flogo apps in apps dir
-func DeleteApps(dir, pattern string) {
+func DeleteApps(dir, pattern, sortBy string) {
 	fmt.Printf("#> Listing all the flogo apps inside apps dir [%s]...\n", dir)
-	files := listAndSort(dir)
-	validApp := regexp.MustCompile(pattern)
+	files := listAndSort(dir, pattern, sortBy)
 	var count int
 	apps := []string{}
 	for i, f := range files {
-		if !f.IsDir() && validApp.MatchString(f.Name()) {
-			apps = append(apps, filepath.Join(dir, f.Name()))
-			count++
-			fmt.Printf("%d. %s\n", i+1, filepath.Join(dir, f.Name()))
-		}
+		apps = append(apps, filepath.Join(dir, f.Name()))
+		count++
+		fmt.Printf("%d. %s\n", i+1, filepath.Join(dir, f.Name()))
 	}
 	if count == 0 {
 		fmt.Println("#> No flogo app found inside apps dir.")
@@ -99,21 +89,47 @@ func DeleteApps(dir, pattern string) {
 	fmt.Println("No app(s) were deleted!")
 }
 
-func listAndSort(dir string) []fs.FileInfo {
-	files, err := ioutil.ReadDir(dir)
+// CompilePattern compiles the app pattern and exits if it is not a valid regex
+func CompilePattern(pattern string) *regexp.Regexp {
+	validApp, err := regexp.Compile(pattern)
 	if err != nil {
-		fmt.Printf("\n#> Failed to read apps dir [%s]! Error %s\n", dir, err.Error())
+		fmt.Printf("\nE> Error ERR_INVALID_PATTERN: app pattern [%s] is not a valid regex: %s\n", pattern, err.Error())
+		fmt.Println("#> You can fix it with: run-flogo-app config set appPattern <pattern>")
 		os.Exit(1)
 	}
-	sort.SliceStable(files, func(i, j int) bool {
-		return files[i].ModTime().After(files[j].ModTime())
-	})
-	var nFiles []fs.FileInfo
+	return validApp
+}
+
+// ListApps returns the flogo apps in dir matching the pattern, the latest
+// app first as per the sort strategy. Only the matching apps are sorted so
+// that the buildtime strategy does not read the other files of the dir.
+func ListApps(dir string, pattern *regexp.Regexp, sortBy string) ([]fs.FileInfo, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var apps []fs.FileInfo
 	selfName := fmt.Sprintf("%s-%s_%s", config.AppName, runtime.GOOS, runtime.GOARCH)
 	for _, f := range files {
-		if !strings.Contains(f.Name(), selfName) {
-			nFiles = append(nFiles, f)
+		// Skip the program itself and the files being downloaded
+		if f.IsDir() || strings.Contains(f.Name(), selfName) || download.IsPartial(f.Name()) {
+			continue
+		}
+		if pattern.MatchString(f.Name()) {
+			apps = append(apps, f)
 		}
 	}
-	return nFiles
+	sortFiles(dir, apps, sortBy)
+	return apps, nil
+}
+
+// listAndSort returns the apps in dir matching the pattern like ListApps and
+// exits if the dir can not be read
+func listAndSort(dir, pattern, sortBy string) []fs.FileInfo {
+	apps, err := ListApps(dir, CompilePattern(pattern), sortBy)
+	if err != nil {
+		fmt.Printf("\n#> Failed to read apps dir [%s]! Error %s\n", dir, err.Error())
+		os.Exit(1)
+	}
+	return apps
 }
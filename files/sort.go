@@ -0,0 +1,100 @@
+package files
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/semver"
+)
+
+// osArchSuffix matches the platform part of the binary name so that it is
+// not mistaken for a pre-release suffix of the version, e.g. app-v1.0.0-linux_amd64
+var osArchSuffix = regexp.MustCompile(`[-_.](linux|windows|darwin)_[0-9A-Za-z]+`)
+
+// sortFiles will sort the files inside dir as per the given strategy with the latest file first
+func sortFiles(dir string, files []fs.FileInfo, sortBy string) {
+	switch sortBy {
+	case config.SortByCreateTime:
+		sortByTime(files, func(f fs.FileInfo) time.Time {
+			return createTime(filepath.Join(dir, f.Name()), f)
+		})
+	case config.SortByBuildTime:
+		fallbacks := 0
+		sortByTime(files, func(f fs.FileInfo) time.Time {
+			t, ok := buildTime(filepath.Join(dir, f.Name()), f)
+			if !ok {
+				fallbacks++
+			}
+			return t
+		})
+		if fallbacks > 0 {
+			fmt.Printf("W> %d of %d apps have no VCS commit time embedded, they are sorted by modification time\n", fallbacks, len(files))
+		}
+	case config.SortBySemver:
+		sortBySemver(files)
+	default:
+		sortByTime(files, fs.FileInfo.ModTime)
+	}
+}
+
+func sortByTime(files []fs.FileInfo, timeOf func(fs.FileInfo) time.Time) {
+	times := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		times[f.Name()] = timeOf(f)
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return times[files[i].Name()].After(times[files[j].Name()])
+	})
+}
+
+// sortBySemver puts the apps with highest version in their name first, apps
+// without a version in their name are placed at the end sorted by ModTime
+func sortBySemver(files []fs.FileInfo) {
+	versions := make(map[string]*semver.Version, len(files))
+	for _, f := range files {
+		versions[f.Name()] = semver.Find(osArchSuffix.ReplaceAllString(f.Name(), ""))
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		vi, vj := versions[files[i].Name()], versions[files[j].Name()]
+		switch {
+		case vi != nil && vj != nil:
+			if c := vi.Compare(vj); c != 0 {
+				return c > 0
+			}
+		case vi != nil:
+			return true
+		case vj != nil:
+			return false
+		}
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+}
+
+// buildTime returns the VCS commit time (vcs.time) embedded by the Go
+// toolchain in the app binary, which is only set when the app is built from a
+// git checkout. It falls back to ModTime, with ok false, if there is none.
+func buildTime(path string, f fs.FileInfo) (t time.Time, ok bool) {
+	if f.IsDir() {
+		return f.ModTime(), false
+	}
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return f.ModTime(), false
+	}
+	for _, s := range info.Settings {
+		if s.Key != "vcs.time" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s.Value)
+		if err == nil {
+			return t, true
+		}
+	}
+	return f.ModTime(), false
+}
@@ -0,0 +1,275 @@
+// Package flogo reads the app descriptor (flogo.json) embedded in the flogo
+// app binaries, e.g. to find the ports of the triggers
+package flogo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Env variables read by the flogo engine to override the app properties
+const (
+	EnvPropsJSON = "FLOGO_APP_PROPS_JSON"
+	EnvPropsEnv  = "FLOGO_APP_PROPS_ENV"
+)
+
+// maxDescriptorSize limits the size of a compressed descriptor once decompressed
+const maxDescriptorSize = 32 << 20
+
+// ErrNoDescriptor is returned when no descriptor is found in the binary
+var ErrNoDescriptor = errors.New("no flogo app descriptor found")
+
+// Descriptor is the flogo.json of an app
+type Descriptor struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Version    string      `json:"version"`
+	AppModel   string      `json:"appModel"`
+	Properties []*Property `json:"properties"`
+	Triggers   []*Trigger  `json:"triggers"`
+}
+
+// Property is an app property which can be overridden at runtime
+type Property struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Trigger is a trigger of the app, e.g. a REST trigger listening on a port
+type Trigger struct {
+	ID       string                 `json:"id"`
+	Ref      string                 `json:"ref"`
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// TriggerPort is a port a trigger of the app listens on
+type TriggerPort struct {
+	TriggerID string
+	Port      int
+	// Property is the app property the port is set from, if any
+	Property string
+}
+
+func (tp *TriggerPort) String() string {
+	if tp.TriggerID == "" {
+		return strconv.Itoa(tp.Port)
+	}
+	if tp.Property != "" {
+		return fmt.Sprintf("%d (trigger %s, property %s)", tp.Port, tp.TriggerID, tp.Property)
+	}
+	return fmt.Sprintf("%d (trigger %s)", tp.Port, tp.TriggerID)
+}
+
+var (
+	appModelKey = []byte(`"appModel"`)
+	// gzipBase64 matches the base64 of a gzip stream, used by the apps built
+	// with the compressed descriptor
+	gzipBase64 = regexp.MustCompile(`H4sI[A-Za-z0-9+/]{16,}={0,2}`)
+	// propertyRef matches a mapping of a setting to a property or env variable
+	propertyRef = regexp.MustCompile(`^=?\$(property|env)\[\s*([^\]]+?)\s*\]$`)
+)
+
+// ReadDescriptor returns the descriptor embedded in the app binary at path,
+// path can also be a flogo.json file
+func ReadDescriptor(path string) (*Descriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		d := new(Descriptor)
+		if err := json.Unmarshal(data, d); err != nil {
+			return nil, fmt.Errorf("invalid flogo app descriptor [%s]: %s", path, err.Error())
+		}
+		return d, nil
+	}
+	if d := findDescriptor(data); d != nil {
+		return d, nil
+	}
+	for _, loc := range gzipBase64.FindAllIndex(data, -1) {
+		if d := decodeCompressed(data[loc[0]:loc[1]]); d != nil {
+			return d, nil
+		}
+	}
+	return nil, ErrNoDescriptor
+}
+
+// findDescriptor looks for the JSON object with the appModel key in the
+// data, the descriptor is stored as a plain string in the binary
+func findDescriptor(data []byte) *Descriptor {
+	const maxLookBack = 4096
+	for offset := 0; ; {
+		i := bytes.Index(data[offset:], appModelKey)
+		if i < 0 {
+			return nil
+		}
+		i += offset
+		offset = i + len(appModelKey)
+		start := i - maxLookBack
+		if start < 0 {
+			start = 0
+		}
+		for j := i - 1; j >= start; j-- {
+			if data[j] != '{' {
+				continue
+			}
+			if d := decodeDescriptor(data[j:]); d != nil {
+				return d
+			}
+		}
+	}
+}
+
+func decodeCompressed(b64 []byte) *Descriptor {
+	compressed := make([]byte, base64.StdEncoding.DecodedLen(len(b64)))
+	n, err := base64.StdEncoding.Decode(compressed, b64)
+	if err != nil {
+		return nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed[:n]))
+	if err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxDescriptorSize))
+	if err != nil {
+		return nil
+	}
+	return decodeDescriptor(data)
+}
+
+// decodeDescriptor decodes the JSON object at the start of data, it returns
+// nil if it is not a flogo app descriptor
+func decodeDescriptor(data []byte) *Descriptor {
+	var m map[string]json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&m); err != nil {
+		return nil
+	}
+	if _, ok := m["appModel"]; !ok {
+		return nil
+	}
+	b, _ := json.Marshal(m)
+	d := new(Descriptor)
+	if json.Unmarshal(b, d) != nil {
+		return nil
+	}
+	return d
+}
+
+// TriggerPorts returns the ports of the triggers with a port setting. The
+// ports set from the app properties are resolved with the overrides in env,
+// the environment the app is run with.
+func (d *Descriptor) TriggerPorts(env []string) []*TriggerPort {
+	var ports []*TriggerPort
+	for _, t := range d.Triggers {
+		v, ok := t.Settings["port"]
+		if !ok {
+			continue
+		}
+		tp := &TriggerPort{TriggerID: t.ID}
+		if s, ok := v.(string); ok {
+			if m := propertyRef.FindStringSubmatch(strings.TrimSpace(s)); m != nil {
+				if m[1] == "property" {
+					tp.Property = m[2]
+					v = d.PropertyValue(m[2], env)
+				} else {
+					v = lookupEnv(env, m[2])
+				}
+			}
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(v)))
+		if err != nil || port <= 0 || port > 65535 {
+			continue
+		}
+		tp.Port = port
+		ports = append(ports, tp)
+	}
+	return ports
+}
+
+// PropertyValue returns the value of the app property as the engine would
+// resolve it with the given environment
+func (d *Descriptor) PropertyValue(name string, env []string) interface{} {
+	if props := lookupEnv(env, EnvPropsJSON); props != "" {
+		var overrides map[string]interface{}
+		if json.Unmarshal([]byte(props), &overrides) == nil {
+			if v, ok := overrides[name]; ok {
+				return v
+			}
+		}
+	}
+	if strings.EqualFold(lookupEnv(env, EnvPropsEnv), "auto") {
+		if v := lookupEnv(env, name); v != "" {
+			return v
+		}
+		if v := lookupEnv(env, strings.ToUpper(strings.ReplaceAll(name, ".", "_"))); v != "" {
+			return v
+		}
+	}
+	for _, p := range d.Properties {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return nil
+}
+
+// SetProperty returns env with the app property overridden in the
+// FLOGO_APP_PROPS_JSON variable, keeping the other overrides in it
+func SetProperty(env []string, name string, value interface{}) ([]string, error) {
+	return SetProperties(env, map[string]interface{}{name: value})
+}
+
+// SetProperties returns env with the app properties overridden in the
+// FLOGO_APP_PROPS_JSON variable, keeping the other overrides in it
+func SetProperties(env []string, props map[string]interface{}) ([]string, error) {
+	overrides := map[string]interface{}{}
+	if props := lookupEnv(env, EnvPropsJSON); props != "" {
+		if err := json.Unmarshal([]byte(props), &overrides); err != nil {
+			return env, fmt.Errorf("invalid %s: %s", EnvPropsJSON, err.Error())
+		}
+	}
+	for name, value := range props {
+		overrides[name] = value
+	}
+	b, err := json.Marshal(overrides)
+	if err != nil {
+		return env, err
+	}
+	return append(env, EnvPropsJSON+"="+string(b)), nil
+}
+
+// ReadProperties reads the app property overrides of the JSON file at path,
+// an object of the property names and their values like FLOGO_APP_PROPS_JSON
+func ReadProperties(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	props := map[string]interface{}{}
+	if err := json.Unmarshal(content, &props); err != nil {
+		return nil, fmt.Errorf("invalid properties file [%s], it must be a JSON object of the property names and values: %s", path, err.Error())
+	}
+	return props, nil
+}
+
+// lookupEnv returns the value of the variable in env, the last one wins
+func lookupEnv(env []string, key string) string {
+	value := ""
+	for _, e := range env {
+		if strings.HasPrefix(e, key+"=") {
+			value = strings.TrimPrefix(e, key+"=")
+		}
+	}
+	return value
+}
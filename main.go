@@ -11,8 +11,13 @@ package main
 
 import (
 	"github.com/abhijitWakchaure/run-flogo-app/cmd"
+	"github.com/abhijitWakchaure/run-flogo-app/sandbox"
 )
 
 func main() {
+	// The program is run as a helper to start the apps with resource limits
+	if sandbox.IsHelper() {
+		sandbox.RunHelper()
+	}
 	cmd.Execute()
 }
@@ -0,0 +1,235 @@
+// Package monitor samples the resource usage of a running app, to spot the
+// memory and file descriptor leaks of the flows in long running tests
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotSupported is returned when the usage cannot be read on this OS
+var ErrNotSupported = errors.New("resource usage is only available on linux")
+
+// CSVHeader is the header of the records returned by Sample.Record
+var CSVHeader = []string{"time", "cpu_percent", "rss_bytes", "fds", "threads", "goroutines"}
+
+// Sample is the resource usage of the app at a point in time, the counts are
+// -1 when they cannot be read
+type Sample struct {
+	Time time.Time
+	// CPU is the percentage of a CPU used since the previous sample
+	CPU        float64
+	RSS        int64
+	FDs        int
+	Threads    int
+	Goroutines int
+}
+
+// Monitor samples the resource usage of a process and keeps a summary of the
+// samples
+type Monitor struct {
+	pid int
+	// metricsURL is the metrics endpoint of the app, e.g. the prometheus
+	// metrics of flogo, read for the goroutine count if set
+	metricsURL string
+	client     *http.Client
+
+	// cpu is the CPU time used by the process at the last sample
+	cpu      time.Duration
+	lastTime time.Time
+	summary  Summary
+}
+
+// Summary sums up the samples taken by a monitor
+type Summary struct {
+	Samples int
+	Start   time.Time
+	End     time.Time
+	// CPUTime is the CPU time used by the app between the first and the last
+	// sample
+	CPUTime                         time.Duration
+	CPUPeak                         float64
+	RSSFirst, RSSLast, RSSPeak      int64
+	FDsFirst, FDsLast, FDsPeak      int
+	ThreadsPeak                     int
+	GoroutinesFirst, GoroutinesLast int
+	GoroutinesPeak                  int
+}
+
+// New returns a monitor for the process with the pid, metricsURL is optional
+func New(pid int, metricsURL string) *Monitor {
+	return &Monitor{
+		pid:        pid,
+		metricsURL: metricsURL,
+		client: &http.Client{
+			Timeout:   time.Second,
+			Transport: &http.Transport{Proxy: nil},
+		},
+	}
+}
+
+// Sample reads the current resource usage of the process
+func (m *Monitor) Sample() (*Sample, error) {
+	u, err := readProc(m.pid)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s := &Sample{Time: now, RSS: u.rss, FDs: u.fds, Threads: u.threads, Goroutines: m.goroutines()}
+	if !m.lastTime.IsZero() {
+		if elapsed := now.Sub(m.lastTime); elapsed > 0 {
+			s.CPU = float64(u.cpu-m.cpu) / float64(elapsed) * 100
+		}
+		m.summary.CPUTime += u.cpu - m.cpu
+	}
+	m.cpu, m.lastTime = u.cpu, now
+	m.add(s)
+	return s, nil
+}
+
+// Run samples the process every interval until the context is done or the
+// process cannot be read anymore, calling fn with each sample
+func (m *Monitor) Run(ctx context.Context, interval time.Duration, fn func(*Sample)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s, err := m.Sample()
+		if err != nil {
+			return err
+		}
+		fn(s)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Summary returns the summary of the samples taken so far
+func (m *Monitor) Summary() Summary {
+	return m.summary
+}
+
+func (m *Monitor) add(s *Sample) {
+	sum := &m.summary
+	if sum.Samples == 0 {
+		sum.Start = s.Time
+		sum.RSSFirst, sum.FDsFirst, sum.GoroutinesFirst = s.RSS, s.FDs, s.Goroutines
+		sum.FDsPeak, sum.ThreadsPeak, sum.GoroutinesPeak = s.FDs, s.Threads, s.Goroutines
+	}
+	sum.Samples++
+	sum.End = s.Time
+	sum.RSSLast, sum.FDsLast = s.RSS, s.FDs
+	// The metrics endpoint is up only once the app is started
+	if s.Goroutines >= 0 {
+		if sum.GoroutinesFirst < 0 {
+			sum.GoroutinesFirst = s.Goroutines
+		}
+		sum.GoroutinesLast = s.Goroutines
+	}
+	if s.CPU > sum.CPUPeak {
+		sum.CPUPeak = s.CPU
+	}
+	if s.RSS > sum.RSSPeak {
+		sum.RSSPeak = s.RSS
+	}
+	if s.FDs > sum.FDsPeak {
+		sum.FDsPeak = s.FDs
+	}
+	if s.Threads > sum.ThreadsPeak {
+		sum.ThreadsPeak = s.Threads
+	}
+	if s.Goroutines > sum.GoroutinesPeak {
+		sum.GoroutinesPeak = s.Goroutines
+	}
+}
+
+// CPUAverage returns the average percentage of a CPU used over the samples
+func (sum *Summary) CPUAverage() float64 {
+	elapsed := sum.End.Sub(sum.Start)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(sum.CPUTime) / float64(elapsed) * 100
+}
+
+// goroutines reads the goroutine count from the metrics endpoint, which can
+// serve the prometheus metrics or a goroutine profile of net/http/pprof
+func (m *Monitor) goroutines() int {
+	if m.metricsURL == "" {
+		return -1
+	}
+	resp, err := m.client.Get(m.metricsURL)
+	if err != nil {
+		return -1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return -1
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var value string
+		if strings.HasPrefix(line, "go_goroutines ") {
+			value = strings.TrimPrefix(line, "go_goroutines ")
+		} else if strings.HasPrefix(line, "goroutine profile: total ") {
+			value = strings.TrimPrefix(line, "goroutine profile: total ")
+		} else {
+			continue
+		}
+		if n, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return int(n)
+		}
+	}
+	return -1
+}
+
+func (s *Sample) String() string {
+	return fmt.Sprintf("cpu %.1f%%, rss %s, fds %s, threads %s, goroutines %s",
+		s.CPU, FormatBytes(s.RSS), count(s.FDs), count(s.Threads), count(s.Goroutines))
+}
+
+// Record returns the sample as a CSV record matching CSVHeader
+func (s *Sample) Record() []string {
+	return []string{
+		s.Time.Format(time.RFC3339),
+		strconv.FormatFloat(s.CPU, 'f', 1, 64),
+		strconv.FormatInt(s.RSS, 10),
+		strconv.Itoa(s.FDs),
+		strconv.Itoa(s.Threads),
+		strconv.Itoa(s.Goroutines),
+	}
+}
+
+// FormatBytes returns the size in a human readable format, e.g. 1.5 MB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < 0 {
+		return "-"
+	}
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func count(n int) string {
+	if n < 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the unit of the CPU times in /proc, USER_HZ is 100 on all the
+// architectures supported by go
+const clockTicks = 100
+
+// usage is the resource usage read from /proc
+type usage struct {
+	cpu     time.Duration
+	rss     int64
+	threads int
+	fds     int
+}
+
+// readProc reads the usage of the process from /proc/<pid>/stat, the open
+// files are counted only for the processes of the same user
+func readProc(pid int) (*usage, error) {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("process %d is not running", pid)
+		}
+		return nil, err
+	}
+	// The command name in parentheses can contain spaces, the fields are
+	// counted from the last parenthesis which is followed by the state
+	stat := string(b)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	if fields[0] == "Z" {
+		return nil, fmt.Errorf("process %d has exited", pid)
+	}
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	threads, _ := strconv.Atoi(fields[17])
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	u := &usage{
+		cpu:     time.Duration(utime+stime) * time.Second / clockTicks,
+		rss:     rss * int64(os.Getpagesize()),
+		threads: threads,
+		fds:     -1,
+	}
+	if entries, err := os.ReadDir(fmt.Sprintf("/proc/%d/fd", pid)); err == nil {
+		u.fds = len(entries)
+	}
+	return u, nil
+}
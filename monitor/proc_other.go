@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package monitor
+
+import "time"
+
+// usage is the resource usage of a process
+type usage struct {
+	cpu     time.Duration
+	rss     int64
+	threads int
+	fds     int
+}
+
+// readProc is only supported on linux
+func readProc(pid int) (*usage, error) {
+	return nil, ErrNotSupported
+}
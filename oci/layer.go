@@ -0,0 +1,204 @@
+package oci
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// layerFile is a file of the layer read from a host path or from data
+type layerFile struct {
+	src  string
+	data []byte
+	mode int64
+}
+
+// buildLayer writes the layer with the app, the CA certificates and the files
+// to the store. It returns the blob and the digest of the uncompressed layer.
+func buildLayer(s *store, o *Options, scratch bool) (*blob, string, error) {
+	files := map[string]*layerFile{}
+	files[AppDir+"/"+baseName(o.App)] = &layerFile{src: o.App, mode: 0755}
+	if len(o.CACerts) > 0 {
+		var certs []byte
+		for _, c := range o.CACerts {
+			b, err := os.ReadFile(c)
+			if err != nil {
+				return nil, "", err
+			}
+			certs = append(certs, b...)
+			if len(b) > 0 && b[len(b)-1] != '\n' {
+				certs = append(certs, '\n')
+			}
+		}
+		files[CACertsPath] = &layerFile{data: certs, mode: 0644}
+	}
+	for _, f := range o.Files {
+		if err := addFiles(files, f); err != nil {
+			return nil, "", err
+		}
+	}
+	dirs := map[string]bool{AppDir: true}
+	if o.WorkingDir != "" {
+		dirs[path.Clean(o.WorkingDir)] = true
+	}
+	for name := range files {
+		for d := path.Dir(name); d != "/"; d = path.Dir(d) {
+			dirs[d] = true
+		}
+	}
+	for d := range dirs {
+		for p := path.Dir(d); p != "/"; p = path.Dir(p) {
+			dirs[p] = true
+		}
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".layer-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.Remove(tmp.Name())
+	digest, diffID := sha256.New(), sha256.New()
+	counter := &countWriter{}
+	gz := gzip.NewWriter(io.MultiWriter(tmp, digest, counter))
+	tw := tar.NewWriter(io.MultiWriter(gz, diffID))
+	err = writeEntries(tw, o, dirs, files, scratch)
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	d := Descriptor{MediaType: MediaTypeLayer, Digest: "sha256:" + hex.EncodeToString(digest.Sum(nil)), Size: counter.n}
+	p, err := s.path(d.Digest)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, "", err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return nil, "", err
+	}
+	return &blob{Descriptor: d, path: p}, "sha256:" + hex.EncodeToString(diffID.Sum(nil)), nil
+}
+
+// writeEntries writes the dirs and the files in order, the parents first
+func writeEntries(tw *tar.Writer, o *Options, dirs map[string]bool, files map[string]*layerFile, scratch bool) error {
+	// The images built on scratch have no /tmp, which the apps may need
+	if scratch {
+		dirs["/tmp"] = true
+	}
+	for _, d := range sortedKeys(dirs) {
+		mode := int64(0755)
+		if d == "/tmp" {
+			mode = 01777
+		}
+		h := &tar.Header{Typeflag: tar.TypeDir, Name: strings.TrimPrefix(d, "/") + "/", Mode: mode, ModTime: o.Created}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeFile(tw, o, name, files[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(tw *tar.Writer, o *Options, name string, f *layerFile) error {
+	h := &tar.Header{Typeflag: tar.TypeReg, Name: strings.TrimPrefix(name, "/"), Mode: f.mode, ModTime: o.Created}
+	if f.data != nil {
+		h.Size = int64(len(f.data))
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		_, err := tw.Write(f.data)
+		return err
+	}
+	src, err := os.Open(f.src)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	h.Size = fi.Size()
+	if err := tw.WriteHeader(h); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+// addFiles adds the file or the files of the dir at f.Src to files, at f.Dst
+// or else in AppDir
+func addFiles(files map[string]*layerFile, f File) error {
+	dst := f.Dst
+	if dst == "" {
+		dst = baseName(f.Src)
+	}
+	if !path.IsAbs(dst) {
+		dst = path.Join(AppDir, dst)
+	}
+	dst = path.Clean(dst)
+	if dst == "/" {
+		return fmt.Errorf("invalid destination of [%s]", f.Src)
+	}
+	return filepath.WalkDir(f.Src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("[%s] is not a regular file", p)
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(f.Src, p)
+		if err != nil {
+			return err
+		}
+		mode := int64(0644)
+		if fi.Mode()&0111 != 0 {
+			mode = 0755
+		}
+		files[path.Join(dst, filepath.ToSlash(rel))] = &layerFile{src: p, mode: mode}
+		return nil
+	})
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (w *countWriter) Write(b []byte) (int, error) {
+	w.n += int64(len(b))
+	return len(b), nil
+}
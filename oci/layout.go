@@ -0,0 +1,348 @@
+package oci
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	layoutFile = "oci-layout"
+	indexFile  = "index.json"
+	layoutJSON = `{"imageLayoutVersion":"1.0.0"}`
+	annotRef   = "org.opencontainers.image.ref.name"
+	annotName  = "io.containerd.image.name"
+)
+
+// loadBase returns the base image for the platform, tmp is used to extract
+// the base tarballs
+func loadBase(ctx context.Context, o *Options, p *Platform, tmp string) (*image, error) {
+	if o.Base == Scratch || o.Base == "" {
+		return &image{config: &Config{}}, nil
+	}
+	fi, err := os.Stat(o.Base)
+	if err != nil {
+		if strings.ContainsAny(o.Base, `/\`) && (strings.HasPrefix(o.Base, ".") || filepath.IsAbs(o.Base)) {
+			return nil, err
+		}
+		return pull(ctx, o.Base, &store{dir: o.CacheDir}, p)
+	}
+	dir := o.Base
+	if !fi.IsDir() {
+		dir = filepath.Join(tmp, "base")
+		if err := extractTar(o.Base, dir); err != nil {
+			return nil, err
+		}
+	}
+	return readLayout(&store{dir: dir}, p)
+}
+
+// readLayout returns the image for the platform from the image layout dir of s
+func readLayout(s *store, p *Platform) (*image, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, indexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("not an OCI image layout, index.json not found")
+		}
+		return nil, err
+	}
+	idx := new(Index)
+	if err := decodeJSON(data, Descriptor{}, idx); err != nil {
+		return nil, err
+	}
+	for depth := 0; ; depth++ {
+		d, err := selectManifest(idx, p)
+		if err != nil {
+			return nil, err
+		}
+		if !isIndex(d.MediaType) {
+			m := new(Manifest)
+			if err := s.readJSON(d, m); err != nil {
+				return nil, err
+			}
+			return imageOf(s, m, p)
+		}
+		if depth > 2 {
+			return nil, errors.New("too many nested indexes")
+		}
+		idx = new(Index)
+		if err := s.readJSON(d, idx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// imageOf returns the image of the manifest, whose blobs are in s
+func imageOf(s *store, m *Manifest, p *Platform) (*image, error) {
+	c := new(Config)
+	if err := s.readJSON(m.Config, c); err != nil {
+		return nil, err
+	}
+	if !p.matches(&Platform{OS: c.OS, Architecture: c.Architecture, Variant: c.Variant}) {
+		return nil, fmt.Errorf("the image is for %s/%s but the app is for %s", c.OS, c.Architecture, p)
+	}
+	if len(c.RootFS.DiffIDs) != len(m.Layers) {
+		return nil, errors.New("the layers of the manifest do not match its config")
+	}
+	img := &image{config: c}
+	for _, l := range m.Layers {
+		if len(l.URLs) > 0 {
+			return nil, fmt.Errorf("foreign layer %s is not supported", l.Digest)
+		}
+		b, ok := s.get(l)
+		if !ok {
+			return nil, fmt.Errorf("layer %s not found", l.Digest)
+		}
+		if b.MediaType == mediaTypeDockerLayer {
+			b.MediaType = MediaTypeLayer
+		}
+		b.Annotations = nil
+		img.layers = append(img.layers, b)
+	}
+	return img, nil
+}
+
+// selectManifest returns the manifest of the index for the platform, or its
+// only manifest if it has no platform
+func selectManifest(idx *Index, p *Platform) (Descriptor, error) {
+	if len(idx.Manifests) == 1 && idx.Manifests[0].Platform == nil {
+		return idx.Manifests[0], nil
+	}
+	var platforms []string
+	for _, d := range idx.Manifests {
+		if p.matches(d.Platform) {
+			return d, nil
+		}
+		if d.Platform != nil && d.Platform.OS != "unknown" {
+			platforms = append(platforms, d.Platform.String())
+		}
+	}
+	if len(idx.Manifests) == 0 {
+		return Descriptor{}, errors.New("the image has no manifest")
+	}
+	return Descriptor{}, fmt.Errorf("no image for %s, the image is for: %s", p, strings.Join(platforms, ", "))
+}
+
+func isIndex(mediaType string) bool {
+	return mediaType == MediaTypeIndex || mediaType == mediaTypeDockerList
+}
+
+// writeLayout writes the image to the image layout dir, which must be empty
+// or an image layout which is replaced
+func writeLayout(dir, name string, manifest *blob, blobs []*blob, p *Platform) error {
+	if err := checkLayoutDir(dir); err != nil {
+		return err
+	}
+	os.RemoveAll(dir)
+	out := &store{dir: dir}
+	for _, b := range blobs {
+		if err := copyBlob(out, b); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, layoutFile), []byte(layoutJSON), 0644); err != nil {
+		return err
+	}
+	idx, err := json.Marshal(layoutIndex(name, manifest, p))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, indexFile), idx, 0644)
+}
+
+// checkLayoutDir returns an error if dir exists and is neither empty nor an
+// image layout, so that no other files are removed
+func checkLayoutDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, layoutFile)); err != nil {
+		return fmt.Errorf("[%s] is not empty and is not an OCI image layout", dir)
+	}
+	return nil
+}
+
+func copyBlob(s *store, b *blob) error {
+	if _, ok := s.get(b.Descriptor); ok {
+		return nil
+	}
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = s.put(f, b.Descriptor)
+	return err
+}
+
+// layoutIndex returns the index.json of the layout, with the name of the
+// image read by docker and containerd
+func layoutIndex(name string, manifest *blob, p *Platform) *Index {
+	d := manifest.Descriptor
+	d.Platform = p
+	if name != "" {
+		d.Annotations = map[string]string{annotName: name}
+		if ref, err := parseReference(name); err == nil {
+			d.Annotations[annotName] = ref.String()
+			if ref.Tag != "" {
+				d.Annotations[annotRef] = ref.Tag
+			}
+		}
+	}
+	return &Index{SchemaVersion: 2, MediaType: MediaTypeIndex, Manifests: []Descriptor{d}}
+}
+
+// dockerManifest is the manifest.json of the tarballs of docker save, which
+// lets the older versions of docker load the image
+type dockerManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// writeTar writes the image layout as a tarball, which also has the
+// manifest.json of docker
+func writeTar(out, name string, manifest *blob, blobs []*blob, p *Platform) error {
+	f, err := os.CreateTemp(filepath.Dir(out), ".part-"+filepath.Base(out)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	tw := tar.NewWriter(f)
+	err = writeTarEntries(tw, name, manifest, blobs, p)
+	if err == nil {
+		err = tw.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), out)
+}
+
+func writeTarEntries(tw *tar.Writer, name string, manifest *blob, blobs []*blob, p *Platform) error {
+	created := time.Unix(0, 0)
+	for _, dir := range []string{"blobs/", "blobs/sha256/"} {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir, Mode: 0755, ModTime: created}); err != nil {
+			return err
+		}
+	}
+	dm := dockerManifest{Config: blobName(blobs[1])}
+	if name != "" {
+		dm.RepoTags = []string{name}
+	}
+	written := map[string]bool{}
+	for _, b := range blobs {
+		if written[b.Digest] {
+			continue
+		}
+		written[b.Digest] = true
+		if err := writeTarBlob(tw, b, created); err != nil {
+			return err
+		}
+	}
+	for _, b := range blobs[2:] {
+		dm.Layers = append(dm.Layers, blobName(b))
+	}
+	idx, err := json.Marshal(layoutIndex(name, manifest, p))
+	if err != nil {
+		return err
+	}
+	dms, err := json.Marshal([]dockerManifest{dm})
+	if err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{{layoutFile, []byte(layoutJSON)}, {indexFile, idx}, {"manifest.json", dms}} {
+		h := &tar.Header{Typeflag: tar.TypeReg, Name: f.name, Mode: 0644, Size: int64(len(f.data)), ModTime: created}
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTarBlob(tw *tar.Writer, b *blob, created time.Time) error {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := &tar.Header{Typeflag: tar.TypeReg, Name: blobName(b), Mode: 0644, Size: b.Size, ModTime: created}
+	if err := tw.WriteHeader(h); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, f, b.Size)
+	return err
+}
+
+func blobName(b *blob) string {
+	return "blobs/sha256/" + strings.TrimPrefix(b.Digest, "sha256:")
+}
+
+// extractTar extracts the image layout tarball at path to dir, only the
+// layout files and the blobs are extracted
+func extractTar(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s := &store{dir: dir}
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid image tarball: %w", err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(filepath.ToSlash(h.Name), "./")
+		switch {
+		case name == indexFile || name == layoutFile:
+			data, err := io.ReadAll(io.LimitReader(tr, maxJSONSize))
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+				return err
+			}
+		case strings.HasPrefix(name, "blobs/sha256/") && !strings.Contains(name[len("blobs/sha256/"):], "/"):
+			d := Descriptor{Digest: "sha256:" + name[len("blobs/sha256/"):], Size: h.Size}
+			if _, err := s.put(tr, d); err != nil {
+				return err
+			}
+		}
+	}
+}
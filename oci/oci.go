@@ -0,0 +1,364 @@
+// Package oci builds the OCI image of an app without a container daemon, from
+// a base image and a layer with the app, the CA certificates and the files of
+// its config. The image is written as an OCI image layout, in a dir or a tarball
+// which can be loaded with docker load, podman load or pushed with skopeo.
+package oci
+
+import (
+	"context"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The media types of the image, the ones of docker are read from the registries
+const (
+	MediaTypeIndex    = "application/vnd.oci.image.index.v1+json"
+	MediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeConfig   = "application/vnd.oci.image.config.v1+json"
+	MediaTypeLayer    = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerLayer    = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+const (
+	// Scratch is the empty base image
+	Scratch = "scratch"
+	// AppDir is the dir of the app in the image
+	AppDir = "/app"
+	// CACertsPath is where the CA certificates are added, the path read by Go
+	// and most distributions
+	CACertsPath = "/etc/ssl/certs/ca-certificates.crt"
+	// defaultPath is the PATH of the images built on scratch
+	defaultPath = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// SystemCACerts are the usual paths of the CA bundle of the systems, the
+// first one found is added to the image by default
+var SystemCACerts = []string{
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/ca-bundle.pem",
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem",
+	"/etc/ssl/cert.pem",
+}
+
+// Descriptor points to a blob of the image
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	URLs        []string          `json:"urls,omitempty"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Platform is the OS and architecture an image runs on
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p *Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// matches returns true if the platform of an image can be used for p, an
+// image without variant matches any variant
+func (p *Platform) matches(other *Platform) bool {
+	return other != nil && other.OS == p.OS && other.Architecture == p.Architecture &&
+		(other.Variant == "" || p.Variant == "" || other.Variant == p.Variant)
+}
+
+// Manifest is the manifest of an image, also used for the docker manifests
+// which have the same layout
+type Manifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Index lists the manifests of an image layout or of a multi platform image
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Config is the configuration of an image
+type Config struct {
+	Created      *time.Time      `json:"created,omitempty"`
+	Author       string          `json:"author,omitempty"`
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	Variant      string          `json:"variant,omitempty"`
+	Config       ContainerConfig `json:"config"`
+	RootFS       RootFS          `json:"rootfs"`
+	History      []History       `json:"history,omitempty"`
+}
+
+// ContainerConfig is how the containers of the image are run
+type ContainerConfig struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Volumes      map[string]struct{} `json:"Volumes,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
+
+// RootFS lists the digests of the uncompressed layers
+type RootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+// History describes how a layer was built
+type History struct {
+	Created    *time.Time `json:"created,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	Comment    string     `json:"comment,omitempty"`
+	EmptyLayer bool       `json:"empty_layer,omitempty"`
+}
+
+// File is a file added to the image, Dst is relative to AppDir
+type File struct {
+	Src string
+	Dst string
+}
+
+// Options holds how the image is built
+type Options struct {
+	// Base is Scratch, the path of an OCI image layout dir or tarball, or the
+	// reference of an image in a registry, e.g. alpine:3
+	Base string
+	// App is the path of the app binary, it is added to AppDir
+	App string
+	// Name is the name of the image with its tag, e.g. order-service:1.2.0
+	Name string
+	// Entrypoint runs the app by default
+	Entrypoint []string
+	Cmd        []string
+	// Env is added to the environment of the base image
+	Env   []string
+	Ports []int
+	// WorkingDir is AppDir by default
+	WorkingDir string
+	User       string
+	// CACerts are the PEM files concatenated into CACertsPath
+	CACerts []string
+	Files   []File
+	Labels  map[string]string
+	// Created is the time of the image and its files, a fixed time makes the
+	// builds reproducible
+	Created time.Time
+	// CacheDir holds the blobs of the base images pulled from the registries
+	CacheDir string
+}
+
+// Result is the image which was built
+type Result struct {
+	// Digest is the digest of the manifest of the image
+	Digest   string
+	Platform *Platform
+	// Size is the compressed size of the layers
+	Size   int64
+	Layers int
+}
+
+// blob is a blob of the image in a store
+type blob struct {
+	Descriptor
+	path string
+}
+
+// image is the base image or the image being built
+type image struct {
+	config *Config
+	layers []*blob
+}
+
+// Build builds the image of the app and writes it to out, as a tarball if it
+// ends with .tar or else as an image layout dir
+func Build(ctx context.Context, out string, o *Options) (*Result, error) {
+	platform, err := AppPlatform(o.App)
+	if err != nil {
+		return nil, err
+	}
+	if samePath(o.Base, out) {
+		return nil, errors.New("the image cannot be written to its base image")
+	}
+	tmp, err := os.MkdirTemp("", "run-flogo-app-image-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	built := &store{dir: tmp}
+	img, err := loadBase(ctx, o, platform, tmp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the base image [%s]: %w", o.Base, err)
+	}
+	layer, diffID, err := buildLayer(built, o, o.Base == Scratch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build the app layer: %w", err)
+	}
+	img.layers = append(img.layers, layer)
+	configure(img.config, o, platform, diffID)
+	configBlob, err := built.putJSON(MediaTypeConfig, img.config)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{SchemaVersion: 2, MediaType: MediaTypeManifest, Config: configBlob.Descriptor}
+	res := &Result{Platform: platform, Layers: len(img.layers)}
+	for _, l := range img.layers {
+		manifest.Layers = append(manifest.Layers, l.Descriptor)
+		res.Size += l.Size
+	}
+	manifestBlob, err := built.putJSON(MediaTypeManifest, manifest)
+	if err != nil {
+		return nil, err
+	}
+	res.Digest = manifestBlob.Digest
+	blobs := append([]*blob{manifestBlob, configBlob}, img.layers...)
+	if strings.HasSuffix(strings.ToLower(out), ".tar") {
+		err = writeTar(out, o.Name, manifestBlob, blobs, platform)
+	} else {
+		err = writeLayout(out, o.Name, manifestBlob, blobs, platform)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// configure sets the options in the config of the base image
+func configure(c *Config, o *Options, p *Platform, diffID string) {
+	created := o.Created.UTC()
+	c.Created = &created
+	c.OS, c.Architecture, c.Variant = p.OS, p.Architecture, p.Variant
+	c.RootFS.Type = "layers"
+	c.RootFS.DiffIDs = append(c.RootFS.DiffIDs, diffID)
+	c.History = append(c.History, History{Created: &created, CreatedBy: "run-flogo-app image", Comment: "app " + path.Base(o.App)})
+	cc := &c.Config
+	if len(cc.Env) == 0 {
+		cc.Env = []string{defaultPath}
+	}
+	cc.Env = mergeEnv(cc.Env, o.Env)
+	cc.Entrypoint, cc.Cmd = o.Entrypoint, o.Cmd
+	if len(cc.Entrypoint) == 0 {
+		cc.Entrypoint = []string{AppDir + "/" + baseName(o.App)}
+	}
+	cc.WorkingDir = o.WorkingDir
+	if cc.WorkingDir == "" {
+		cc.WorkingDir = AppDir
+	}
+	if o.User != "" {
+		cc.User = o.User
+	}
+	if len(o.Ports) > 0 && cc.ExposedPorts == nil {
+		cc.ExposedPorts = map[string]struct{}{}
+	}
+	for _, port := range o.Ports {
+		cc.ExposedPorts[strconv.Itoa(port)+"/tcp"] = struct{}{}
+	}
+	if len(o.Labels) > 0 && cc.Labels == nil {
+		cc.Labels = map[string]string{}
+	}
+	for k, v := range o.Labels {
+		cc.Labels[k] = v
+	}
+}
+
+// mergeEnv returns env with the variables of overrides, replacing the ones
+// with the same name
+func mergeEnv(env, overrides []string) []string {
+	merged := append([]string{}, env...)
+	index := map[string]int{}
+	for i, e := range merged {
+		index[strings.SplitN(e, "=", 2)[0]] = i
+	}
+	for _, e := range overrides {
+		name := strings.SplitN(e, "=", 2)[0]
+		if i, ok := index[name]; ok {
+			merged[i] = e
+			continue
+		}
+		index[name] = len(merged)
+		merged = append(merged, e)
+	}
+	return merged
+}
+
+// AppPlatform returns the platform of the app from its ELF header
+func AppPlatform(path string) (*Platform, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, errors.New("the app is not a linux executable, the image needs the linux build of the app")
+	}
+	defer f.Close()
+	p := &Platform{OS: "linux"}
+	switch f.Machine {
+	case elf.EM_X86_64:
+		p.Architecture = "amd64"
+	case elf.EM_386:
+		p.Architecture = "386"
+	case elf.EM_AARCH64:
+		p.Architecture, p.Variant = "arm64", "v8"
+	case elf.EM_ARM:
+		p.Architecture, p.Variant = "arm", "v7"
+	case elf.EM_PPC64:
+		p.Architecture = "ppc64le"
+	case elf.EM_S390:
+		p.Architecture = "s390x"
+	case elf.EM_RISCV:
+		p.Architecture = "riscv64"
+	default:
+		return nil, fmt.Errorf("unsupported architecture of the app: %s", f.Machine)
+	}
+	return p, nil
+}
+
+// baseName returns the name of the file at path, which can be a host path
+func baseName(p string) string {
+	p = strings.ReplaceAll(p, `\`, "/")
+	return path.Base(p)
+}
+
+// samePath returns true if both paths are the same file
+func samePath(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	return err == nil && os.SameFile(fa, fb)
+}
+
+// sortedKeys returns the keys of m in order, for reproducible outputs
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
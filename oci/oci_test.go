@@ -0,0 +1,213 @@
+package oci
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testOptions returns the options building an image of the test binary, which
+// is an executable of the platform the tests run on
+func testOptions(t *testing.T, base string) *Options {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AppPlatform(exe); err != nil {
+		t.Skipf("the test binary cannot be used as an app: %s", err)
+	}
+	cert := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(cert, []byte("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &Options{
+		Base:     base,
+		App:      exe,
+		Name:     "example.com/orders:1.0.0",
+		Cmd:      []string{"--verbose"},
+		Env:      []string{"FLOGO_LOG_LEVEL=DEBUG"},
+		Ports:    []int{9999},
+		User:     "65534:65534",
+		CACerts:  []string{cert},
+		Created:  time.Unix(1700000000, 0),
+		CacheDir: t.TempDir(),
+	}
+}
+
+// checkImage reads the image of the layout dir and checks its digests
+func checkImage(t *testing.T, dir, digest string, layers int) *image {
+	t.Helper()
+	s := &store{dir: dir}
+	idx := new(Index)
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Manifests) != 1 || idx.Manifests[0].Digest != digest {
+		t.Fatalf("index.json has %+v, want the manifest %s", idx.Manifests, digest)
+	}
+	if got := idx.Manifests[0].Annotations[annotRef]; got != "1.0.0" {
+		t.Errorf("ref name annotation is %q, want 1.0.0", got)
+	}
+	p, _ := AppPlatform(os.Args[0])
+	img, err := readLayout(s, p)
+	if err != nil {
+		t.Fatalf("unable to read the image: %s", err)
+	}
+	if len(img.layers) != layers || len(img.config.RootFS.DiffIDs) != layers {
+		t.Fatalf("image has %d layers and %d diff_ids, want %d", len(img.layers), len(img.config.RootFS.DiffIDs), layers)
+	}
+	for i, l := range img.layers {
+		f, err := os.Open(l.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, zr); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if diffID := "sha256:" + hex.EncodeToString(h.Sum(nil)); diffID != img.config.RootFS.DiffIDs[i] {
+			t.Errorf("layer %d has diff_id %s, the config has %s", i, diffID, img.config.RootFS.DiffIDs[i])
+		}
+	}
+	cc := img.config.Config
+	if want := AppDir + "/" + filepath.Base(os.Args[0]); len(cc.Entrypoint) != 1 || cc.Entrypoint[0] != want {
+		t.Errorf("entrypoint is %v, want [%s]", cc.Entrypoint, want)
+	}
+	if _, ok := cc.ExposedPorts["9999/tcp"]; !ok {
+		t.Errorf("exposed ports are %v, want 9999/tcp", cc.ExposedPorts)
+	}
+	if !strings.Contains(strings.Join(cc.Env, " "), "FLOGO_LOG_LEVEL=DEBUG") {
+		t.Errorf("env is %v, want FLOGO_LOG_LEVEL=DEBUG", cc.Env)
+	}
+	return img
+}
+
+// readTarFile returns the content of the file name of the tarball
+func readTarFile(t *testing.T, tarball, name string) []byte {
+	t.Helper()
+	f, err := os.Open(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			t.Fatalf("%s not found in the tarball: %s", name, err)
+		}
+		if h.Name == name {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data
+		}
+	}
+}
+
+func TestBuildRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	layout := filepath.Join(dir, "layout")
+	res, err := Build(ctx, layout, testOptions(t, Scratch))
+	if err != nil {
+		t.Fatalf("unable to build the image layout: %s", err)
+	}
+	if res.Layers != 1 {
+		t.Errorf("image has %d layers, want 1", res.Layers)
+	}
+	img := checkImage(t, layout, res.Digest, 1)
+
+	tarball := filepath.Join(dir, "image.tar")
+	tarRes, err := Build(ctx, tarball, testOptions(t, Scratch))
+	if err != nil {
+		t.Fatalf("unable to build the image tarball: %s", err)
+	}
+	if tarRes.Digest != res.Digest {
+		t.Errorf("the tarball has the manifest %s, the layout has %s", tarRes.Digest, res.Digest)
+	}
+	extracted := filepath.Join(dir, "extracted")
+	if err := extractTar(tarball, extracted); err != nil {
+		t.Fatalf("unable to extract the tarball: %s", err)
+	}
+	checkImage(t, extracted, res.Digest, 1)
+	data := readTarFile(t, tarball, "manifest.json")
+	var dockerManifest []struct {
+		Config   string
+		RepoTags []string
+		Layers   []string
+	}
+	if err := json.Unmarshal(data, &dockerManifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(dockerManifest) != 1 || len(dockerManifest[0].Layers) != 1 || len(dockerManifest[0].RepoTags) != 1 || dockerManifest[0].RepoTags[0] != "example.com/orders:1.0.0" {
+		t.Errorf("manifest.json is %+v", dockerManifest)
+	}
+
+	// The layout and the tarball can be used as base images
+	for _, base := range []string{layout, tarball} {
+		out := filepath.Join(dir, "on-"+strings.TrimSuffix(filepath.Base(base), ".tar"))
+		res, err := Build(ctx, out, testOptions(t, base))
+		if err != nil {
+			t.Fatalf("unable to build on %s: %s", base, err)
+		}
+		onBase := checkImage(t, out, res.Digest, 2)
+		if onBase.layers[0].Digest != img.layers[0].Digest {
+			t.Errorf("the first layer is %s, want the layer of the base %s", onBase.layers[0].Digest, img.layers[0].Digest)
+		}
+	}
+}
+
+func TestAuthenticateRealm(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Basic dXNlcjpwYXNz" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"token":"t0k3n"}`))
+	}))
+	defer srv.Close()
+	challenge := `Bearer realm="` + srv.URL + `/token",service="registry"`
+
+	rg := &registry{ref: &reference{Host: "registry.example.com", Repo: "orders"}, credentials: "dXNlcjpwYXNz"}
+	if err := rg.authenticate(context.Background(), challenge); err == nil || !strings.Contains(err.Error(), "not https") {
+		t.Errorf("http realm of a remote registry is not refused: %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("the credentials were sent to the http realm of a remote registry")
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	rg = &registry{ref: &reference{Host: host, Repo: "orders"}, credentials: "dXNlcjpwYXNz"}
+	if err := rg.authenticate(context.Background(), challenge); err != nil {
+		t.Fatalf("http realm of a local registry is refused: %s", err)
+	}
+	if rg.auth != "Bearer t0k3n" {
+		t.Errorf("auth is %q, want the token of the realm", rg.auth)
+	}
+}
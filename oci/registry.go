@@ -0,0 +1,352 @@
+package oci
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+	"github.com/abhijitWakchaure/run-flogo-app/monitor"
+)
+
+const (
+	dockerHub = "docker.io"
+	// dockerHubRegistry is the host of the registry of docker hub
+	dockerHubRegistry = "registry-1.docker.io"
+	// dockerHubAuthKey is the key of docker hub in the docker config file
+	dockerHubAuthKey = "https://index.docker.io/v1/"
+)
+
+var (
+	validRepo = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	validTag  = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	// challengeParam matches the params of a WWW-Authenticate header
+	challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// manifestTypes are the manifests accepted from the registries
+var manifestTypes = []string{MediaTypeIndex, MediaTypeManifest, mediaTypeDockerList, mediaTypeDockerManifest}
+
+// reference is the reference of an image in a registry, e.g. alpine:3
+type reference struct {
+	Host   string
+	Repo   string
+	Tag    string
+	Digest string
+}
+
+// parseReference parses an image reference, the images without registry
+// are on docker hub and the ones without tag or digest are the latest
+func parseReference(s string) (*reference, error) {
+	r := &reference{Host: dockerHub}
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		r.Digest, name = name[i+1:], name[:i]
+		if !validDigest.MatchString(r.Digest) {
+			return nil, fmt.Errorf("invalid digest in image reference [%s]", s)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		r.Tag, name = name[i+1:], name[:i]
+		if !validTag.MatchString(r.Tag) {
+			return nil, fmt.Errorf("invalid tag in image reference [%s]", s)
+		}
+	}
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		r.Host, name = parts[0], parts[1]
+	}
+	if r.Host == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if !validRepo.MatchString(name) {
+		return nil, fmt.Errorf("invalid image reference [%s]", s)
+	}
+	r.Repo = name
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+	return r, nil
+}
+
+func (r *reference) String() string {
+	s := r.Host + "/" + r.Repo
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// registry pulls the images of a repo with the registry API
+type registry struct {
+	ref  *reference
+	base string
+	// auth is the Authorization header of the requests, once authenticated
+	auth string
+	// credentials are the base64 credentials of the docker config file
+	credentials string
+}
+
+func newRegistry(ref *reference) *registry {
+	host, scheme := ref.Host, "https"
+	if host == dockerHub {
+		host = dockerHubRegistry
+	}
+	// Like docker, the local registries can be reached without TLS
+	if isLocalhost(host) {
+		scheme = "http"
+	}
+	return &registry{
+		ref:         ref,
+		base:        scheme + "://" + host + "/v2/" + ref.Repo,
+		credentials: dockerCredentials(ref.Host),
+	}
+}
+
+// isLocalhost returns true if the host, with an optional port, is the local
+// machine
+func isLocalhost(host string) bool {
+	h := strings.Split(host, ":")[0]
+	return h == "localhost" || h == "127.0.0.1"
+}
+
+// pull pulls the image for the platform into the store
+func pull(ctx context.Context, s string, cache *store, p *Platform) (*image, error) {
+	ref, err := parseReference(s)
+	if err != nil {
+		return nil, err
+	}
+	rg := newRegistry(ref)
+	fmt.Printf("#> Pulling the base image %s for %s\n", ref, p)
+	tag := ref.Digest
+	if tag == "" {
+		tag = ref.Tag
+	}
+	mediaType, data, err := rg.manifest(ctx, tag, ref.Digest)
+	if err != nil {
+		return nil, err
+	}
+	for depth := 0; isIndex(mediaType); depth++ {
+		idx := new(Index)
+		if err := json.Unmarshal(data, idx); err != nil {
+			return nil, err
+		}
+		d, err := selectManifest(idx, p)
+		if err != nil {
+			return nil, err
+		}
+		if depth > 2 {
+			return nil, errors.New("too many nested indexes")
+		}
+		if mediaType, data, err = rg.manifest(ctx, d.Digest, d.Digest); err != nil {
+			return nil, err
+		}
+	}
+	if mediaType != MediaTypeManifest && mediaType != mediaTypeDockerManifest {
+		return nil, fmt.Errorf("unsupported manifest type [%s]", mediaType)
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	for _, d := range append([]Descriptor{m.Config}, m.Layers...) {
+		if err := rg.blob(ctx, cache, d); err != nil {
+			return nil, err
+		}
+	}
+	return imageOf(cache, m, p)
+}
+
+// manifest returns the media type and the content of the manifest with the
+// tag or digest, which is checked against digest if set
+func (rg *registry) manifest(ctx context.Context, tag, digest string) (string, []byte, error) {
+	resp, err := rg.get(ctx, "/manifests/"+tag, strings.Join(manifestTypes, ", "))
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJSONSize+1))
+	if err != nil {
+		return "", nil, err
+	}
+	var v struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := decodeJSON(data, Descriptor{Digest: digest}, &v); err != nil {
+		return "", nil, fmt.Errorf("invalid manifest %s: %w", tag, err)
+	}
+	mediaType := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	if v.MediaType != "" {
+		mediaType = v.MediaType
+	}
+	return mediaType, data, nil
+}
+
+// blob pulls the blob into the store, unless it is already there
+func (rg *registry) blob(ctx context.Context, s *store, d Descriptor) error {
+	if _, ok := s.get(d); ok {
+		return nil
+	}
+	if len(d.URLs) > 0 {
+		return fmt.Errorf("foreign layer %s is not supported", d.Digest)
+	}
+	if d.MediaType != MediaTypeConfig && !strings.HasSuffix(d.MediaType, "json") {
+		fmt.Printf("#> Pulling layer %s (%s)\n", d.Digest[:19], monitor.FormatBytes(d.Size))
+	}
+	resp, err := rg.get(ctx, "/blobs/"+d.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = s.put(resp.Body, d)
+	return err
+}
+
+// get sends a GET to the path of the repo, it authenticates with the
+// challenge of the registry if needed
+func (rg *registry) get(ctx context.Context, path, accept string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rg.base+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if rg.auth != "" {
+			req.Header.Set("Authorization", rg.auth)
+		}
+		resp, err := download.Client().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			if err := rg.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return nil, fmt.Errorf("bad status from [%s]: %s", req.URL.Redacted(), resp.Status)
+	}
+}
+
+// authenticate gets a token from the auth server of the challenge, with the
+// credentials of the docker config file if any
+func (rg *registry) authenticate(ctx context.Context, challenge string) error {
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	if scheme == "basic" {
+		if rg.credentials == "" {
+			return fmt.Errorf("the registry %s needs credentials, login with docker login", rg.ref.Host)
+		}
+		rg.auth = "Basic " + rg.credentials
+		return nil
+	}
+	if scheme != "bearer" {
+		return fmt.Errorf("unsupported authentication of the registry [%s]", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid authentication realm of the registry [%s]", challenge)
+	}
+	// The credentials are sent to the realm, which is only trusted over TLS
+	// unless the registry is a local one
+	if realm.Scheme != "https" && !isLocalhost(rg.ref.Host) {
+		return fmt.Errorf("refusing the authentication realm [%s] of the registry %s which is not https", realm.Redacted(), rg.ref.Host)
+	}
+	q := realm.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + rg.ref.Repo + ":pull"
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if rg.credentials != "" {
+		req.Header.Set("Authorization", "Basic "+rg.credentials)
+	}
+	resp, err := download.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to authenticate to the registry %s: %s", rg.ref.Host, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJSONSize)).Decode(&token); err != nil {
+		return err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("no token from the auth server of the registry %s", rg.ref.Host)
+	}
+	rg.auth = "Bearer " + token.Token
+	return nil
+}
+
+// dockerCredentials returns the base64 credentials of the host saved in the
+// docker config file by docker login, the credential helpers are not supported
+func dockerCredentials(host string) string {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return ""
+	}
+	keys := []string{host, "https://" + host, "http://" + host}
+	if host == dockerHub {
+		keys = []string{dockerHubAuthKey, dockerHub, dockerHubRegistry}
+	}
+	for _, k := range keys {
+		if a, ok := cfg.Auths[k]; ok && a.Auth != "" {
+			if _, err := base64.StdEncoding.DecodeString(a.Auth); err == nil {
+				return a.Auth
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package oci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// maxJSONSize limits the size of the manifests, indexes and configs read
+const maxJSONSize = 4 << 20
+
+var validDigest = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// store holds blobs by digest in dir/blobs/sha256, like an image layout
+type store struct {
+	dir string
+}
+
+// path returns the path of the blob with the digest
+func (s *store) path(digest string) (string, error) {
+	if !validDigest.MatchString(digest) {
+		return "", fmt.Errorf("unsupported digest [%s]", digest)
+	}
+	return filepath.Join(s.dir, "blobs", "sha256", digest[len("sha256:"):]), nil
+}
+
+// get returns the blob of the descriptor if it is in the store
+func (s *store) get(d Descriptor) (*blob, bool) {
+	p, err := s.path(d.Digest)
+	if err != nil {
+		return nil, false
+	}
+	if fi, err := os.Stat(p); err != nil || fi.Size() != d.Size {
+		return nil, false
+	}
+	return &blob{Descriptor: d, path: p}, true
+}
+
+// put writes the blob read from r, which must match the descriptor
+func (s *store) put(r io.Reader, d Descriptor) (*blob, error) {
+	p, err := s.path(d.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), ".part-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(r, d.Size+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n != d.Size {
+		return nil, fmt.Errorf("blob %s has %d bytes instead of %d", d.Digest, n, d.Size)
+	}
+	if digest := "sha256:" + hex.EncodeToString(h.Sum(nil)); digest != d.Digest {
+		return nil, fmt.Errorf("blob %s has the digest %s", d.Digest, digest)
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return nil, err
+	}
+	return &blob{Descriptor: d, path: p}, nil
+}
+
+// putJSON writes v as a blob of the media type
+func (s *store) putJSON(mediaType string, v interface{}) (*blob, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(b)
+	d := Descriptor{MediaType: mediaType, Digest: "sha256:" + hex.EncodeToString(sum[:]), Size: int64(len(b))}
+	p, err := s.path(d.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(p, b, 0644); err != nil {
+		return nil, err
+	}
+	return &blob{Descriptor: d, path: p}, nil
+}
+
+// readJSON decodes the blob of the descriptor into v, checking its digest
+func (s *store) readJSON(d Descriptor, v interface{}) error {
+	b, ok := s.get(d)
+	if !ok {
+		return fmt.Errorf("blob %s not found", d.Digest)
+	}
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, d, v)
+}
+
+// decodeJSON decodes the blob data of the descriptor into v, checking its
+// size and digest
+func decodeJSON(data []byte, d Descriptor, v interface{}) error {
+	if int64(len(data)) > maxJSONSize {
+		return fmt.Errorf("blob %s is too large", d.Digest)
+	}
+	if d.Digest != "" {
+		sum := sha256.Sum256(data)
+		if digest := "sha256:" + hex.EncodeToString(sum[:]); digest != d.Digest {
+			return fmt.Errorf("blob %s has the digest %s", d.Digest, digest)
+		}
+	}
+	return json.Unmarshal(data, v)
+}
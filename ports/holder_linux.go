@@ -0,0 +1,88 @@
+package ports
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the state of a listening socket in /proc/net/tcp
+const tcpListen = "0A"
+
+// FindHolder returns the process listening on the TCP port. The sockets are
+// looked up in /proc/net/tcp and tcp6 and matched with the open files of the
+// processes, the processes of other users are found only when run as root.
+func FindHolder(port int) (*Holder, error) {
+	inodes := map[string]bool{}
+	for _, f := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		listenInodes(f, port, inodes)
+	}
+	if len(inodes) == 0 {
+		return nil, fmt.Errorf("no listening socket found on port %d", port)
+	}
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", d.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			if inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+				return processInfo(pid), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("the process listening on port %d is not visible, it may belong to another user", port)
+}
+
+// listenInodes adds the inodes of the sockets listening on the port in the
+// /proc/net/tcp formatted file
+func listenInodes(path string, port int, inodes map[string]bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Scan() // header
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 10 || fields[3] != tcpListen {
+			continue
+		}
+		i := strings.LastIndex(fields[1], ":")
+		p, err := strconv.ParseInt(fields[1][i+1:], 16, 32)
+		if err != nil || int(p) != port {
+			continue
+		}
+		inodes[fields[9]] = true
+	}
+}
+
+func processInfo(pid int) *Holder {
+	h := &Holder{PID: pid}
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	if b, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		h.Name = strings.TrimSpace(string(b))
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		h.Cmdline = strings.TrimSpace(string(bytes.ReplaceAll(b, []byte{0}, []byte(" "))))
+	}
+	return h
+}
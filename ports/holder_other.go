@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package ports
+
+// FindHolder is only supported on linux
+func FindHolder(port int) (*Holder, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,77 @@
+// Package ports checks if the ports used by an app are free and finds the
+// local process holding a port
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ErrNotSupported is returned when the holder of a port can not be looked up on the OS
+var ErrNotSupported = errors.New("finding the process holding a port is not supported on this OS")
+
+// Holder is a local process listening on a port
+type Holder struct {
+	PID     int
+	Name    string
+	Cmdline string
+}
+
+func (h *Holder) String() string {
+	if h.Cmdline != "" {
+		return fmt.Sprintf("pid %d (%s)", h.PID, h.Cmdline)
+	}
+	return fmt.Sprintf("pid %d (%s)", h.PID, h.Name)
+}
+
+// Check returns an error if the TCP port can not be bound on all the
+// interfaces, like the flogo triggers do
+func Check(port int) error {
+	l, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
+
+// Free returns the first free port after port, or 0 if there is none in the
+// next 100 ports
+func Free(port int, exclude map[int]bool) int {
+	for p := port + 1; p <= port+100 && p <= 65535; p++ {
+		if !exclude[p] && Check(p) == nil {
+			return p
+		}
+	}
+	return 0
+}
+
+// Kill stops the process holding the port, it is killed if it has not freed
+// the port within the timeout after being asked to terminate
+func Kill(h *Holder, port int, timeout time.Duration) error {
+	p, err := os.FindProcess(h.PID)
+	if err != nil {
+		return err
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		return p.Kill()
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if Check(port) == nil {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := p.Kill(); err != nil {
+		return err
+	}
+	for i := 0; i < 20 && Check(port) != nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	return Check(port)
+}
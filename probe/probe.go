@@ -0,0 +1,158 @@
+// Package probe checks if a launched app is ready, by an HTTP request, a TCP
+// connect or a line in its logs
+package probe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds a single check of a probe
+const checkTimeout = 2 * time.Second
+
+// Probe checks if the app is ready
+type Probe interface {
+	// Check returns nil if the app is ready
+	Check(ctx context.Context) error
+	String() string
+}
+
+// httpClient is used for the HTTP probes, it does not use any proxy as the
+// app runs locally
+var httpClient = &http.Client{
+	Transport: &http.Transport{Proxy: nil},
+	Timeout:   checkTimeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// HTTP is ready when a GET on the URL returns a response which is not a server error
+type HTTP struct {
+	URL string
+}
+
+// Check ...
+func (p *HTTP) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("GET %s returned %s", p.URL, resp.Status)
+	}
+	return nil
+}
+
+func (p *HTTP) String() string {
+	return "http " + p.URL
+}
+
+// TCP is ready when a connection to the address succeeds
+type TCP struct {
+	Addr string
+}
+
+// Check ...
+func (p *TCP) Check(ctx context.Context) error {
+	d := net.Dialer{Timeout: checkTimeout}
+	conn, err := d.DialContext(ctx, "tcp", p.Addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (p *TCP) String() string {
+	return "tcp " + p.Addr
+}
+
+// Log is ready when a line of the app output matches the pattern, the output
+// of the app has to be written to it
+type Log struct {
+	Pattern *regexp.Regexp
+
+	mu      sync.Mutex
+	line    []byte
+	matched bool
+}
+
+// maxLineSize limits the buffered part of a line without a line break
+const maxLineSize = 64 << 10
+
+// Write scans the output of the app for the pattern
+func (p *Log) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.matched {
+		return len(b), nil
+	}
+	data := append(p.line, b...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if p.Pattern.Match(data[:i]) {
+			p.matched = true
+			p.line = nil
+			return len(b), nil
+		}
+		data = data[i+1:]
+	}
+	if len(data) > maxLineSize {
+		data = data[len(data)-maxLineSize:]
+	}
+	p.line = append([]byte{}, data...)
+	return len(b), nil
+}
+
+// Check ...
+func (p *Log) Check(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.matched || (len(p.line) > 0 && p.Pattern.Match(p.line)) {
+		return nil
+	}
+	return fmt.Errorf("no log line matched [%s] yet", p.Pattern.String())
+}
+
+func (p *Log) String() string {
+	return "log " + p.Pattern.String()
+}
+
+// Wait checks the probes every interval until all of them are ready, it
+// returns the error of a probe which is not ready when ctx is done
+func Wait(ctx context.Context, probes []Probe, interval time.Duration) error {
+	pending := append([]Probe{}, probes...)
+	var lastErr error
+	for {
+		var failed []Probe
+		for _, p := range pending {
+			if err := p.Check(ctx); err != nil {
+				lastErr = fmt.Errorf("%s: %s", p.String(), err.Error())
+				failed = append(failed, p)
+			}
+		}
+		pending = failed
+		if len(pending) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return lastErr
+		case <-time.After(interval):
+		}
+	}
+}
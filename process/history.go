@@ -0,0 +1,67 @@
+package process
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+)
+
+// Actions recorded in the run history
+const (
+	ActionRun     = "run"
+	ActionStart   = "start"
+	ActionRestart = "restart"
+	ActionStop    = "stop"
+)
+
+// Event is an entry of the run history
+type Event struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// ID is the id of the instance, empty for the apps run in the foreground
+	ID   string   `json:"id,omitempty"`
+	PID  int      `json:"pid"`
+	Name string   `json:"name"`
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
+}
+
+func historyPath() string {
+	return filepath.Join(config.StateDir(), config.HistoryFileName)
+}
+
+// Record adds the event to the run history, only the last events are kept
+func Record(e *Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	return config.Update(historyPath(), func(m map[string]interface{}) {
+		events, _ := m["events"].([]interface{})
+		events = append(events, e)
+		if len(events) > config.MaxHistory {
+			events = events[len(events)-config.MaxHistory:]
+		}
+		m["events"] = events
+	})
+}
+
+// History returns the run history, the latest event first
+func History() ([]*Event, error) {
+	m, err := config.ReadFile(historyPath())
+	if err != nil {
+		return nil, err
+	}
+	b, _ := json.Marshal(m["events"])
+	var events []*Event
+	json.Unmarshal(b, &events)
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+func record(action string, inst *Instance) {
+	Record(&Event{Action: action, ID: inst.ID, PID: inst.PID, Name: inst.Name, Path: inst.Path, Args: inst.Args})
+}
@@ -0,0 +1,69 @@
+package process
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// startTime returns the start time of the process in clock ticks since boot,
+// field 22 of /proc/<pid>/stat, or 0 where /proc is not available
+func startTime(pid int) uint64 {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0
+	}
+	// The fields after the command name, which is in parentheses, start with
+	// the state, field 3
+	i := bytes.LastIndexByte(b, ')')
+	if i < 0 {
+		return 0
+	}
+	fields := strings.Fields(string(b[i+1:]))
+	if len(fields) < 20 {
+		return 0
+	}
+	t, _ := strconv.ParseUint(fields[19], 10, 64)
+	return t
+}
+
+// executable returns the path of the executable of the process, or "" where
+// /proc is not available
+func executable(pid int) string {
+	exe, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/exe")
+	if err != nil {
+		return ""
+	}
+	// The app may have been replaced by a new download while running
+	return strings.TrimSuffix(exe, " (deleted)")
+}
+
+// identify records the start time and the executable of the started process
+func (i *Instance) identify() {
+	i.StartTime = startTime(i.PID)
+	i.Exe = executable(i.PID)
+}
+
+// identified returns true if the process with the pid of the instance is
+// still the one which was started, and not another process which got the pid
+// after a reboot or a wraparound. The process started the app, or the helper
+// of the sandbox which executes it.
+func (i *Instance) identified() bool {
+	if i.StartTime != 0 && startTime(i.PID) != i.StartTime {
+		return false
+	}
+	exe := executable(i.PID)
+	if exe == "" {
+		return i.Exe == ""
+	}
+	if exe == i.Exe {
+		return true
+	}
+	path, err := filepath.EvalSymlinks(i.Path)
+	if err != nil {
+		path = i.Path
+	}
+	return exe == path
+}
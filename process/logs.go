@@ -0,0 +1,109 @@
+package process
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// pollInterval is how often a followed log file is checked for new output
+const pollInterval = 250 * time.Millisecond
+
+// Logs writes the last lines of the log file to w, all of them if lines is
+// negative. With follow, the output appended later is written too until ctx
+// is done.
+func Logs(ctx context.Context, path string, lines int, follow bool, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	offset, err := tailOffset(f, lines)
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	var n int64
+	if err == nil {
+		n, err = io.Copy(w, f)
+	}
+	f.Close()
+	if err != nil || !follow {
+		return err
+	}
+	return Follow(ctx, path, offset+n, w)
+}
+
+// Follow writes the output appended to the log file after offset to w until
+// ctx is done, the file is read from the start again if it is truncated
+func Follow(ctx context.Context, path string, offset int64, w io.Writer) error {
+	for {
+		if fi, err := os.Stat(path); err == nil && fi.Size() != offset {
+			if fi.Size() < offset {
+				offset = 0
+			}
+			n, err := copyFrom(path, offset, w)
+			offset += n
+			if err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func copyFrom(path string, offset int64, w io.Writer) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, f)
+}
+
+// tailOffset returns the offset of the last lines in the file, the file is
+// read backwards in chunks so that large log files are not read completely
+func tailOffset(f *os.File, lines int) (int64, error) {
+	if lines < 0 {
+		return 0, nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+	if lines == 0 {
+		return size, nil
+	}
+	buf := make([]byte, 64<<10)
+	count := 0
+	// The line break ending the file does not start a new line
+	end := size - 1
+	for end > 0 {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' {
+				continue
+			}
+			count++
+			if count == lines {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
@@ -0,0 +1,123 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/sandbox"
+)
+
+// Start starts the app of the instance in the background with its output
+// written to the log file, the instance is added to the registry. The
+// returned command is started, the caller has to wait for it or release it.
+func Start(inst *Instance) (*exec.Cmd, error) {
+	if inst.Name == "" {
+		inst.Name = filepath.Base(inst.Path)
+	}
+	if inst.ID == "" {
+		if err := reserve(inst); err != nil {
+			return nil, err
+		}
+	}
+	cmd, err := start(inst, os.O_TRUNC)
+	if err != nil {
+		Remove(inst.ID)
+		return nil, err
+	}
+	record(ActionStart, inst)
+	return cmd, nil
+}
+
+// start starts the app, flag is the mode of the log file, O_TRUNC for a new
+// instance and O_APPEND for a restart
+func start(inst *Instance, flag int) (*exec.Cmd, error) {
+	if inst.LogFile == "" {
+		inst.LogFile = filepath.Join(config.LogsDir(), fmt.Sprintf("%s-%s.log", inst.ID, inst.Name))
+	}
+	if err := config.MkdirPrivate(filepath.Dir(inst.LogFile)); err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(inst.LogFile, os.O_CREATE|os.O_WRONLY|flag, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close()
+	// The log of a restart may be created by an older version
+	if err := logFile.Chmod(0600); err != nil {
+		return nil, err
+	}
+	cmd, err := sandbox.Command(inst.Path, inst.Args, append(sandbox.Environ(inst.Sandbox), inst.Env...), inst.Sandbox)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Dir == "" {
+		cmd.Dir = inst.Dir
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = sysProcAttr(cmd.SysProcAttr)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	inst.PID = cmd.Process.Pid
+	inst.StartedAt = time.Now().UTC()
+	inst.identify()
+	if err := save(inst); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// Stop asks the app to terminate and kills it if it is still running after
+// the timeout, the instance is kept in the registry
+func Stop(inst *Instance, timeout time.Duration) error {
+	if !inst.Running() {
+		return nil
+	}
+	if err := terminate(inst.PID); err != nil {
+		return err
+	}
+	defer record(ActionStop, inst)
+	if waitExit(inst.PID, timeout) {
+		return nil
+	}
+	if err := kill(inst.PID); err != nil {
+		return err
+	}
+	if !waitExit(inst.PID, 2*time.Second) {
+		return fmt.Errorf("process %d is still running after being killed", inst.PID)
+	}
+	return nil
+}
+
+// Restart stops the app if it is running and starts it again with the same
+// id, args and environment, its output is appended to the log file
+func Restart(inst *Instance, timeout time.Duration) (*exec.Cmd, error) {
+	if err := Stop(inst, timeout); err != nil {
+		return nil, err
+	}
+	cmd, err := start(inst, os.O_APPEND)
+	if err != nil {
+		return nil, err
+	}
+	record(ActionRestart, inst)
+	return cmd, nil
+}
+
+// waitExit returns true if the process exits within the timeout
+func waitExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for alive(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
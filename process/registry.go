@@ -0,0 +1,143 @@
+// Package process runs the flogo apps in the background and keeps track of
+// them in a registry in the state dir, with their output in a log file
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/sandbox"
+)
+
+// Instance is an app started in the background
+type Instance struct {
+	ID   string   `json:"id"`
+	PID  int      `json:"pid"`
+	Name string   `json:"name"`
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
+	// Env holds the variables set for the app on top of the environment
+	Env       []string  `json:"env,omitempty"`
+	Dir       string    `json:"dir,omitempty"`
+	LogFile   string    `json:"logFile"`
+	StartedAt time.Time `json:"startedAt"`
+	// StartTime and Exe identify the process, as its pid can be reused
+	StartTime uint64 `json:"startTime,omitempty"`
+	Exe       string `json:"exe,omitempty"`
+	// Sandbox holds the limits the app is run with, if any
+	Sandbox *sandbox.Options `json:"sandbox,omitempty"`
+}
+
+// Running returns true if the process of the instance is alive and is still
+// the one which was started
+func (i *Instance) Running() bool {
+	return i.PID > 0 && alive(i.PID) && i.identified()
+}
+
+// Status returns running or exited
+func (i *Instance) Status() string {
+	if i.Running() {
+		return "running"
+	}
+	return "exited"
+}
+
+// registryPath returns the path of the file with the instances, keyed by their id
+func registryPath() string {
+	return filepath.Join(config.StateDir(), config.ProcessesFileName)
+}
+
+// List returns the instances in the registry ordered by their id
+func List() ([]*Instance, error) {
+	m, err := config.ReadFile(registryPath())
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	registry := map[string]*Instance{}
+	if err := json.Unmarshal(b, &registry); err != nil {
+		return nil, fmt.Errorf("invalid registry [%s]: %s", registryPath(), err.Error())
+	}
+	var instances []*Instance
+	for id, inst := range registry {
+		inst.ID = id
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return idNumber(instances[i].ID) < idNumber(instances[j].ID)
+	})
+	return instances, nil
+}
+
+// Find returns the instance with the id, or else the only instance with the
+// (partial) name, the name is matched case insensitive
+func Find(query string) (*Instance, error) {
+	instances, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var matched []*Instance
+	var ids []string
+	for _, inst := range instances {
+		if inst.ID == query {
+			return inst, nil
+		}
+		if strings.Contains(strings.ToLower(inst.Name), strings.ToLower(query)) {
+			matched = append(matched, inst)
+			ids = append(ids, inst.ID)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("no app found with id or name [%s], see the apps with 'run-flogo-app ps -a'", query)
+	case 1:
+		return matched[0], nil
+	}
+	return nil, fmt.Errorf("[%s] matches %d apps with ids %s, use the id instead", query, len(matched), strings.Join(ids, ", "))
+}
+
+// Remove removes the instance from the registry, its log file is kept
+func Remove(id string) error {
+	return config.Update(registryPath(), func(m map[string]interface{}) {
+		delete(m, id)
+	})
+}
+
+// reserve adds the instance to the registry with a new id, the smallest
+// number greater than all the ids in use. The state dir is only accessible by
+// the user as the registry has the env of the apps.
+func reserve(inst *Instance) error {
+	if err := config.MkdirPrivate(config.StateDir()); err != nil {
+		return err
+	}
+	return config.Update(registryPath(), func(m map[string]interface{}) {
+		next := 1
+		for id := range m {
+			if n := idNumber(id); n >= next {
+				next = n + 1
+			}
+		}
+		inst.ID = strconv.Itoa(next)
+		m[inst.ID] = inst
+	})
+}
+
+func save(inst *Instance) error {
+	return config.Update(registryPath(), func(m map[string]interface{}) {
+		m[inst.ID] = inst
+	})
+}
+
+func idNumber(id string) int {
+	n, _ := strconv.Atoi(id)
+	return n
+}
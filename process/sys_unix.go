@@ -0,0 +1,54 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package process
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// sysProcAttr starts the app in a new session, so it is not stopped with the
+// terminal it is started from, attr is added to if set
+func sysProcAttr(attr *syscall.SysProcAttr) *syscall.SysProcAttr {
+	if attr == nil {
+		attr = new(syscall.SysProcAttr)
+	}
+	attr.Setsid = true
+	return attr
+}
+
+// terminate sends SIGTERM to the process group of the app, which is led by it
+func terminate(pid int) error {
+	return signal(pid, syscall.SIGTERM)
+}
+
+func kill(pid int) error {
+	return signal(pid, syscall.SIGKILL)
+}
+
+func signal(pid int, sig syscall.Signal) error {
+	if err := syscall.Kill(-pid, sig); err == nil {
+		return nil
+	}
+	return syscall.Kill(pid, sig)
+}
+
+func alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return (err == nil || err == syscall.EPERM) && !zombie(pid)
+}
+
+// zombie returns true if the process has exited but is not reaped by its
+// parent yet, which is only detected where /proc is available
+func zombie(pid int) bool {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// The state follows the command name, which is in parentheses
+	i := bytes.LastIndexByte(b, ')')
+	return i >= 0 && i+2 < len(b) && b[i+2] == 'Z'
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package sandbox
+
+const helperSupported = false
+
+func runHelper(hc *helperConfig, path string, args []string) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,52 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package sandbox
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"syscall"
+)
+
+const helperSupported = true
+
+// runHelper sets up the process with the limits and executes the app
+func runHelper(hc *helperConfig, path string, args []string) error {
+	if err := setup(hc); err != nil {
+		return err
+	}
+	if err := setRlimits(hc.Options); err != nil {
+		return err
+	}
+	return syscall.Exec(path, append([]string{path}, args...), os.Environ())
+}
+
+func setRlimits(o *Options) error {
+	// The memory is limited by the cgroup if any, which limits the RSS like
+	// the containers do instead of the virtual memory
+	if o.Memory > 0 && !o.UsesCgroup() {
+		if err := setRlimit(syscall.RLIMIT_AS, uint64(o.Memory), "virtual memory"); err != nil {
+			return err
+		}
+	}
+	if o.CPUTime > 0 {
+		if err := setRlimit(syscall.RLIMIT_CPU, uint64(math.Ceil(o.CPUTime.Seconds())), "CPU time"); err != nil {
+			return err
+		}
+	}
+	if o.OpenFiles > 0 {
+		if err := setRlimit(syscall.RLIMIT_NOFILE, o.OpenFiles, "open files"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setRlimit(resource int, limit uint64, name string) error {
+	if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: limit, Max: limit}); err != nil {
+		return fmt.Errorf("unable to limit the %s to %d: %w", name, limit, err)
+	}
+	return nil
+}
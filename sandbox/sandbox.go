@@ -0,0 +1,254 @@
+// Package sandbox runs the apps with resource limits, in linux namespaces and
+// cgroups, to reproduce locally the limits of the containers they run in.
+//
+// The limits are applied by the program itself, run as a helper with the
+// helper arg, which sets them on its own process and then executes the app.
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/monitor"
+)
+
+const (
+	// helperArg is the first arg of the program run as the helper
+	helperArg = "__sandbox"
+	// helperEnv holds the options of the helper, it is removed from the
+	// environment of the app
+	helperEnv = "RUN_FLOGO_APP_SANDBOX"
+	// cleanPath is the PATH of a clean environment, the default of docker
+	cleanPath = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// Namespaces are the linux namespaces the app can be isolated in
+var Namespaces = []string{"pid", "net", "ipc", "uts", "mount"}
+
+// ErrNotSupported is returned when the limits are not supported on this OS
+var ErrNotSupported = errors.New("not supported on this OS")
+
+// Options holds the limits and the isolation of the app
+type Options struct {
+	// Memory limits the RSS of the app with a cgroup, or else its virtual
+	// memory with RLIMIT_AS, in bytes
+	Memory int64 `json:"memory,omitempty"`
+	// CPUTime limits the CPU time of the app with RLIMIT_CPU
+	CPUTime   time.Duration `json:"cpuTime,omitempty"`
+	OpenFiles uint64        `json:"openFiles,omitempty"`
+	// CPUs and PIDs are limited with a cgroup
+	CPUs float64 `json:"cpus,omitempty"`
+	PIDs int     `json:"pids,omitempty"`
+	// Cgroup runs the app in a cgroup v2, it is implied by the cgroup limits
+	Cgroup     bool     `json:"cgroup,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Workdir is the working directory of the app, created if missing
+	Workdir string `json:"workdir,omitempty"`
+	// CleanEnv runs the app without the environment of the program
+	CleanEnv bool `json:"cleanEnv,omitempty"`
+}
+
+// helperConfig is passed to the helper
+type helperConfig struct {
+	*Options
+	// CgroupDir is the cgroup prepared for the app
+	CgroupDir string `json:"cgroupDir,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+}
+
+// Validate checks the options and makes the workdir absolute
+func (o *Options) Validate() error {
+	if o.Memory < 0 || o.CPUTime < 0 || o.CPUs < 0 || o.PIDs < 0 {
+		return errors.New("the limits must not be negative")
+	}
+	for _, ns := range o.Namespaces {
+		if !contains(Namespaces, ns) {
+			return fmt.Errorf("unknown namespace [%s], valid namespaces are: %s", ns, strings.Join(Namespaces, ", "))
+		}
+	}
+	if o.Workdir != "" {
+		dir, err := filepath.Abs(o.Workdir)
+		if err != nil {
+			return err
+		}
+		o.Workdir = dir
+	}
+	return nil
+}
+
+// UsesCgroup returns true if the app is run in a cgroup
+func (o *Options) UsesCgroup() bool {
+	return o.Cgroup || o.CPUs > 0 || o.PIDs > 0
+}
+
+// Isolates returns true if the app is run in the namespace
+func (o *Options) Isolates(ns string) bool {
+	return contains(o.Namespaces, ns)
+}
+
+// needsHelper returns true if the app has to be started by the helper
+func (o *Options) needsHelper() bool {
+	return o.Memory > 0 || o.CPUTime > 0 || o.OpenFiles > 0 || o.UsesCgroup() || len(o.Namespaces) > 0
+}
+
+func (o *Options) String() string {
+	var s []string
+	if o.Memory > 0 {
+		kind := "virtual memory"
+		if o.UsesCgroup() {
+			kind = "memory"
+		}
+		s = append(s, fmt.Sprintf("%s %s", kind, monitor.FormatBytes(o.Memory)))
+	}
+	if o.CPUs > 0 {
+		s = append(s, "cpus "+strconv.FormatFloat(o.CPUs, 'f', -1, 64))
+	}
+	if o.CPUTime > 0 {
+		s = append(s, "cpu time "+o.CPUTime.String())
+	}
+	if o.OpenFiles > 0 {
+		s = append(s, fmt.Sprintf("open files %d", o.OpenFiles))
+	}
+	if o.PIDs > 0 {
+		s = append(s, fmt.Sprintf("pids %d", o.PIDs))
+	}
+	if o.UsesCgroup() {
+		s = append(s, "cgroup")
+	}
+	if len(o.Namespaces) > 0 {
+		s = append(s, "namespaces "+strings.Join(o.Namespaces, ","))
+	}
+	if o.Workdir != "" {
+		s = append(s, "workdir "+o.Workdir)
+	}
+	if o.CleanEnv {
+		s = append(s, "clean env")
+	}
+	return strings.Join(s, ", ")
+}
+
+// Environ returns the environment the variables of the app are added to,
+// which only has a PATH with a clean environment
+func Environ(o *Options) []string {
+	if o != nil && o.CleanEnv {
+		return []string{cleanPath}
+	}
+	return os.Environ()
+}
+
+// Command returns the command running the app with the options, which is run
+// by the helper if there are limits. The options can be nil.
+func Command(path string, args, env []string, o *Options) (*exec.Cmd, error) {
+	if o == nil {
+		cmd := exec.Command(path, args...)
+		cmd.Env = env
+		return cmd, nil
+	}
+	if o.Workdir != "" {
+		if err := os.MkdirAll(o.Workdir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	if !o.needsHelper() {
+		cmd := exec.Command(path, args...)
+		cmd.Env = env
+		cmd.Dir = o.Workdir
+		return cmd, nil
+	}
+	if !helperSupported {
+		return nil, fmt.Errorf("the resource limits are %w", ErrNotSupported)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	hc := &helperConfig{Options: o}
+	if o.Isolates("uts") {
+		hc.Hostname = name(path)
+	}
+	if o.UsesCgroup() {
+		if hc.CgroupDir, err = createCgroup(path, o); err != nil {
+			return nil, fmt.Errorf("unable to create the cgroup: %w", err)
+		}
+	}
+	b, err := json.Marshal(hc)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(self, append([]string{helperArg, path}, args...)...)
+	cmd.Env = append(append([]string{}, env...), helperEnv+"="+string(b))
+	cmd.Dir = o.Workdir
+	if cmd.SysProcAttr, err = sysProcAttr(o); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// IsHelper returns true if the program is run as the helper
+func IsHelper() bool {
+	return len(os.Args) > 2 && os.Args[1] == helperArg && os.Getenv(helperEnv) != ""
+}
+
+// RunHelper applies the limits to the process and executes the app in it,
+// it only returns if the app cannot be executed
+func RunHelper() {
+	hc := new(helperConfig)
+	if err := json.Unmarshal([]byte(os.Getenv(helperEnv)), hc); err != nil || hc.Options == nil {
+		helperFailed(fmt.Errorf("invalid %s: %v", helperEnv, err))
+	}
+	os.Unsetenv(helperEnv)
+	if err := runHelper(hc, os.Args[2], os.Args[3:]); err != nil {
+		helperFailed(err)
+	}
+}
+
+func helperFailed(err error) {
+	fmt.Fprintf(os.Stderr, "E> Error ERR_SANDBOX: %s\n", err.Error())
+	os.Exit(127)
+}
+
+// ParseBytes parses a size like 512M or 1.5GB, with binary units
+func ParseBytes(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "B"), "I")
+	mult := int64(1)
+	if i := strings.IndexAny(v, "KMGT"); i >= 0 && i == len(v)-1 {
+		mult = int64(1) << (10 * (strings.IndexByte("KMGT", v[i]) + 1))
+		v = v[:i]
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size [%s], use a number of bytes or a size like 512M or 1.5G", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// name returns the name of the app usable as a hostname or a cgroup name
+func name(path string) string {
+	n := []byte(filepath.Base(path))
+	for i, c := range n {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			n[i] = '-'
+		}
+	}
+	if len(n) > 48 {
+		n = n[:48]
+	}
+	return strings.Trim(string(n), "-")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup"
+	// cgroupParent holds the cgroups of the apps
+	cgroupParent = cgroupRoot + "/run-flogo-app"
+	// cpuPeriod is the period of the CPU quota in microseconds
+	cpuPeriod = 100000
+)
+
+var cloneFlags = map[string]uintptr{
+	"pid":   syscall.CLONE_NEWPID,
+	"net":   syscall.CLONE_NEWNET,
+	"ipc":   syscall.CLONE_NEWIPC,
+	"uts":   syscall.CLONE_NEWUTS,
+	"mount": syscall.CLONE_NEWNS,
+}
+
+// sysProcAttr starts the helper in the namespaces, and in a user namespace
+// mapping the user to root when not run as root, as the helper needs the
+// capabilities in the namespaces to set them up
+func sysProcAttr(o *Options) (*syscall.SysProcAttr, error) {
+	if len(o.Namespaces) == 0 {
+		return nil, nil
+	}
+	attr := new(syscall.SysProcAttr)
+	for _, ns := range o.Namespaces {
+		attr.Cloneflags |= cloneFlags[ns]
+	}
+	// /proc is mounted again for the pid namespace, in a mount namespace
+	if o.Isolates("pid") {
+		attr.Cloneflags |= syscall.CLONE_NEWNS
+	}
+	if os.Geteuid() != 0 {
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Geteuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getegid(), Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+	}
+	return attr, nil
+}
+
+// createCgroup creates a cgroup for the app with the limits, the helper moves
+// itself into it before executing the app
+func createCgroup(path string, o *Options) (string, error) {
+	available, err := os.ReadFile(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	if err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	}
+	var controllers []string
+	if o.Memory > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if o.CPUs > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if o.PIDs > 0 {
+		controllers = append(controllers, "pids")
+	}
+	if err := os.Mkdir(cgroupParent, 0755); err != nil && !os.IsExist(err) {
+		if os.IsPermission(err) {
+			return "", fmt.Errorf("%w, the cgroups can only be created as root", err)
+		}
+		return "", err
+	}
+	for _, c := range controllers {
+		if !contains(strings.Fields(string(available)), c) {
+			return "", fmt.Errorf("the %s controller is not available in cgroup v2, it may be used by cgroup v1", c)
+		}
+		for _, dir := range []string{cgroupRoot, cgroupParent} {
+			if err := writeFile(filepath.Join(dir, "cgroup.subtree_control"), "+"+c); err != nil {
+				return "", fmt.Errorf("unable to enable the %s controller: %w", c, err)
+			}
+		}
+	}
+	Cleanup()
+	dir, err := os.MkdirTemp(cgroupParent, name(path)+"-")
+	if err != nil {
+		return "", err
+	}
+	limits := map[string]string{}
+	if o.Memory > 0 {
+		limits["memory.max"] = strconv.FormatInt(o.Memory, 10)
+	}
+	if o.CPUs > 0 {
+		limits["cpu.max"] = fmt.Sprintf("%d %d", int64(o.CPUs*cpuPeriod), cpuPeriod)
+	}
+	if o.PIDs > 0 {
+		limits["pids.max"] = strconv.Itoa(o.PIDs)
+	}
+	for file, value := range limits {
+		if err := writeFile(filepath.Join(dir, file), value); err != nil {
+			os.Remove(dir)
+			return "", fmt.Errorf("unable to set %s: %w", file, err)
+		}
+	}
+	// Like in the containers the app does not swap, if swap is enabled
+	if o.Memory > 0 {
+		writeFile(filepath.Join(dir, "memory.swap.max"), "0")
+	}
+	return dir, nil
+}
+
+// Cleanup removes the cgroups of the apps which have exited, a cgroup with
+// processes cannot be removed
+func Cleanup() {
+	entries, err := os.ReadDir(cgroupParent)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			syscall.Rmdir(filepath.Join(cgroupParent, e.Name()))
+		}
+	}
+}
+
+// setup runs in the helper, in the namespaces, before the app is executed
+func setup(hc *helperConfig) error {
+	if hc.CgroupDir != "" {
+		// 0 is the writing process, whose pid differs in a pid namespace
+		if err := writeFile(filepath.Join(hc.CgroupDir, "cgroup.procs"), "0"); err != nil {
+			return fmt.Errorf("unable to join the cgroup: %w", err)
+		}
+	}
+	if hc.Isolates("net") {
+		if err := loopbackUp(); err != nil {
+			return fmt.Errorf("unable to set up the loopback interface: %w", err)
+		}
+	}
+	if hc.Isolates("pid") {
+		// The mounts must not propagate to the host before /proc is mounted
+		// for the processes of the namespace
+		if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+			return fmt.Errorf("unable to make the mounts private: %w", err)
+		}
+		if err := syscall.Mount("proc", "/proc", "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+			return fmt.Errorf("unable to mount /proc: %w", err)
+		}
+	}
+	if hc.Hostname != "" {
+		if err := syscall.Sethostname([]byte(hc.Hostname)); err != nil {
+			return fmt.Errorf("unable to set the hostname: %w", err)
+		}
+	}
+	return nil
+}
+
+// loopbackUp brings up the loopback interface, which is down in a new
+// network namespace, so that the app can listen on it
+func loopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return err
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr)
+}
+
+func writeFile(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if errors.Is(err, syscall.EBUSY) {
+		return fmt.Errorf("%w, the cgroup has processes", err)
+	}
+	return err
+}
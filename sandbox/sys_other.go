@@ -0,0 +1,28 @@
+//go:build !linux
+// +build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"syscall"
+)
+
+// sysProcAttr fails if there are namespaces, which are only supported on linux
+func sysProcAttr(o *Options) (*syscall.SysProcAttr, error) {
+	if len(o.Namespaces) > 0 {
+		return nil, errors.New("the namespaces are only supported on linux")
+	}
+	return nil, nil
+}
+
+func createCgroup(path string, o *Options) (string, error) {
+	return "", errors.New("the cgroups are only supported on linux")
+}
+
+func setup(hc *helperConfig) error {
+	return nil
+}
+
+// Cleanup removes the cgroups of the apps which have exited
+func Cleanup() {}
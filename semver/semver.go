@@ -0,0 +1,124 @@
+// Package semver parses and compares semantic versions found in tags and file names
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionRegex = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?`)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+	Original   string
+}
+
+// Parse will parse the given string as a semantic version, the leading 'v' is optional
+func Parse(s string) (*Version, error) {
+	s = strings.TrimSpace(s)
+	m := versionRegex.FindStringSubmatchIndex(s)
+	if m == nil || m[0] != 0 || m[1] != len(s) {
+		return nil, fmt.Errorf("invalid semantic version [%s]", s)
+	}
+	return fromMatch(s, m), nil
+}
+
+// Find will return the first semantic version found inside the given string or nil
+func Find(s string) *Version {
+	m := versionRegex.FindStringSubmatchIndex(s)
+	if m == nil {
+		return nil
+	}
+	return fromMatch(s, m)
+}
+
+func fromMatch(s string, m []int) *Version {
+	v := &Version{Original: s[m[0]:m[1]]}
+	v.Major, _ = strconv.Atoi(s[m[2]:m[3]])
+	v.Minor, _ = strconv.Atoi(s[m[4]:m[5]])
+	v.Patch, _ = strconv.Atoi(s[m[6]:m[7]])
+	if m[8] >= 0 {
+		v.PreRelease = s[m[8]:m[9]]
+	}
+	return v
+}
+
+// IsPreRelease returns true if the version has a pre-release suffix
+func (v *Version) IsPreRelease() bool {
+	return v.PreRelease != ""
+}
+
+// String returns the version in vX.Y.Z[-pre] format
+func (v *Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to or greater than o.
+// Build metadata is ignored as per the semver spec
+func (v *Version) Compare(o *Version) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePreRelease(v.PreRelease, o.PreRelease)
+}
+
+func comparePreRelease(a, b string) int {
+	// A version without pre-release has higher precedence
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+	ap := strings.Split(a, ".")
+	bp := strings.Split(b, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(ap), len(bp))
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
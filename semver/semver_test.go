@@ -0,0 +1,116 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		pre   string
+		valid bool
+	}{
+		{"v1.2.3", "v1.2.3", "", true},
+		{"1.2.3", "v1.2.3", "", true},
+		{" v1.2.3\n", "v1.2.3", "", true},
+		{"v10.20.30", "v10.20.30", "", true},
+		{"v1.0.0-beta.1", "v1.0.0-beta.1", "beta.1", true},
+		{"v1.0.0-rc-1", "v1.0.0-rc-1", "rc-1", true},
+		{"v1.0.0+build.5", "v1.0.0", "", true},
+		{"v1.0.0-alpha+001", "v1.0.0-alpha", "alpha", true},
+		{"v1.2", "", "", false},
+		{"version 1.2.3", "", "", false},
+		{"v1.2.3 latest", "", "", false},
+		{"v1.2.x", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v, err := Parse(tt.in)
+			if !tt.valid {
+				if err == nil {
+					t.Fatalf("invalid version is parsed as %s", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.String() != tt.want || v.PreRelease != tt.pre || v.IsPreRelease() != (tt.pre != "") {
+				t.Errorf("version is %s with pre-release %q, want %s with %q", v, v.PreRelease, tt.want, tt.pre)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v1.3.0", "v1.2.9", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.0.0+build.1", "v1.0.0+build.2", 0},
+		// The examples of the precedence in the semver spec
+		{"v1.0.0-alpha", "v1.0.0", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-alpha.beta", "v1.0.0-beta", -1},
+		{"v1.0.0-beta", "v1.0.0-beta.2", -1},
+		{"v1.0.0-beta.2", "v1.0.0-beta.11", -1},
+		{"v1.0.0-beta.11", "v1.0.0-rc.1", -1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := Parse(tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := Parse(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("%s compared to %s is %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := b.Compare(a); got != -tt.want {
+				t.Errorf("%s compared to %s is %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"orders-v1.2.3", "v1.2.3"},
+		{"orders-1.2.3", "v1.2.3"},
+		{"orders-v1.2.3-rc.1", "v1.2.3-rc.1"},
+		{"orders-v1.2.3-rc.1.exe", "v1.2.3-rc.1.exe"},
+		{"orders_v2.0.0_final", "v2.0.0"},
+		{"v1.0.0 and v2.0.0", "v1.0.0"},
+		{"orders-v1.2", ""},
+		{"orders", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v := Find(tt.in)
+			if tt.want == "" {
+				if v != nil {
+					t.Fatalf("version %s is found", v)
+				}
+				return
+			}
+			if v == nil || v.String() != tt.want {
+				t.Errorf("version is %v, want %s", v, tt.want)
+			}
+		})
+	}
+}
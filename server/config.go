@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+)
+
+// configKeyView is a key which can be set in the config file with its value
+type configKeyView struct {
+	Name        string      `json:"name"`
+	Kind        string      `json:"kind"`
+	Description string      `json:"description"`
+	Value       interface{} `json:"value"`
+}
+
+// configView is the config file with the keys which can be set
+type configView struct {
+	File string           `json:"file"`
+	Keys []*configKeyView `json:"keys"`
+}
+
+// getConfig returns the keys which can be set with their value in the config file
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request, params []string) {
+	view, err := readConfig()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, view)
+}
+
+func readConfig() (*configView, error) {
+	path := config.SettingsFilePath()
+	m, err := config.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	view := &configView{File: path, Keys: []*configKeyView{}}
+	for _, spec := range config.Schema {
+		if !spec.Settable {
+			continue
+		}
+		kv := &configKeyView{Name: spec.Name, Kind: spec.Kind, Description: spec.Description}
+		for k, v := range m {
+			if strings.EqualFold(k, spec.Name) {
+				kv.Value = v
+			}
+		}
+		view.Keys = append(view.Keys, kv)
+	}
+	return view, nil
+}
+
+// setConfig sets the key in the config file to the value in a body like
+// {"value": ...}, the value is validated like config set does
+func (s *Server) setConfig(w http.ResponseWriter, r *http.Request, params []string) {
+	spec := settableKey(w, params[0])
+	if spec == nil {
+		return
+	}
+	var body struct {
+		Value interface{} `json:"value"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if dir, ok := body.Value.(string); ok && spec.Name == "appsDir" && !filepath.IsAbs(dir) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("apps dir [%s] must be an absolute path", dir))
+		return
+	}
+	value, err := config.ParseValue(spec, body.Value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.updateConfig(w, spec.Name, value)
+}
+
+// unsetConfig removes the key from the config file so that its default is used
+func (s *Server) unsetConfig(w http.ResponseWriter, r *http.Request, params []string) {
+	spec := settableKey(w, params[0])
+	if spec == nil {
+		return
+	}
+	s.updateConfig(w, spec.Name, nil)
+}
+
+// updateConfig writes the value of the key, nil removes it, and applies the
+// settings used by the server
+func (s *Server) updateConfig(w http.ResponseWriter, key string, value interface{}) {
+	err := config.UpdateSettings(func(m map[string]interface{}) {
+		for k := range m {
+			if strings.EqualFold(k, key) {
+				delete(m, k)
+			}
+		}
+		if value != nil {
+			m[key] = value
+		}
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defaults := app.DefaultAppConfig()
+	s.mu.Lock()
+	switch key {
+	case "appsDir":
+		s.appsDir = defaults.AppsDir
+		if value != nil {
+			s.appsDir = value.(string)
+		}
+	case "appPattern":
+		pattern := defaults.AppPattern
+		if value != nil {
+			pattern = value.(string)
+		}
+		// The pattern is checked to be valid by its key spec
+		s.appPattern = regexp.MustCompile(pattern)
+	case "sortBy":
+		s.sortBy = defaults.SortBy
+		if value != nil {
+			s.sortBy = value.(string)
+		}
+	}
+	s.mu.Unlock()
+	s.getConfig(w, nil, nil)
+}
+
+func settableKey(w http.ResponseWriter, key string) *config.KeySpec {
+	spec := config.LookupKey(key)
+	if spec == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown config key [%s]", key))
+		return nil
+	}
+	if !spec.Settable {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("key [%s] can not be set, the keys which can be set are: %s", spec.Name, strings.Join(config.SettableKeys(), ", ")))
+		return nil
+	}
+	return spec
+}
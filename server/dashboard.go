@@ -0,0 +1,18 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// dashboardPage is the single page dashboard using the API
+//
+//go:embed dashboard/index.html
+var dashboardPage []byte
+
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request, params []string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Write(dashboardPage)
+}
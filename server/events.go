@@ -0,0 +1,38 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// eventWriter writes the lines written to it as server-sent events, a partial
+// line is sent once it is complete
+type eventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	line    []byte
+}
+
+func (ew *eventWriter) Write(b []byte) (int, error) {
+	data := append(ew.line, b...)
+	sent := false
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		// A carriage return would end the event data too
+		line := bytes.ReplaceAll(data[:i], []byte("\r"), nil)
+		if _, err := fmt.Fprintf(ew.w, "data: %s\n\n", line); err != nil {
+			return 0, err
+		}
+		data = data[i+1:]
+		sent = true
+	}
+	ew.line = append([]byte{}, data...)
+	if sent {
+		ew.flusher.Flush()
+	}
+	return len(b), nil
+}
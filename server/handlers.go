@@ -0,0 +1,279 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/app"
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/files"
+	"github.com/abhijitWakchaure/run-flogo-app/process"
+)
+
+// maxBodySize limits the size of the request bodies
+const maxBodySize = 1 << 20
+
+// appView is an app in the apps dir
+type appView struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// Flogo is the info from the app descriptor embedded in the binary
+	Flogo *appInfo `json:"flogo,omitempty"`
+}
+
+// processView is an app running in the background with its status
+type processView struct {
+	*process.Instance
+	Status string `json:"status"`
+}
+
+func newProcessView(inst *process.Instance) *processView {
+	return &processView{Instance: inst, Status: inst.Status()}
+}
+
+// runRequest is the body of a request to run an app, the app is the (partial)
+// name of the app, the latest app is run if empty
+type runRequest struct {
+	App      string   `json:"app"`
+	Args     []string `json:"args"`
+	Env      []string `json:"env"`
+	LogLevel string   `json:"logLevel"`
+	Ports    []int    `json:"ports"`
+}
+
+func (s *Server) version(w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, map[string]string{"version": config.VERSION})
+}
+
+// listApps lists the apps, the latest first, filtered by the name query param
+func (s *Server) listApps(w http.ResponseWriter, r *http.Request, params []string) {
+	apps, err := s.findApps(r.URL.Query().Get("name"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(apps) {
+		apps = apps[:limit]
+	}
+	for _, a := range apps {
+		a.Flogo = s.appInfo(a)
+	}
+	writeJSON(w, http.StatusOK, apps)
+}
+
+func (s *Server) findApps(name string) ([]*appView, error) {
+	appsDir, pattern, sortBy := s.settings()
+	infos, err := files.ListApps(appsDir, pattern, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	apps := []*appView{}
+	for _, f := range infos {
+		if strings.Contains(strings.ToLower(f.Name()), strings.ToLower(name)) {
+			apps = append(apps, &appView{Name: f.Name(), Path: filepath.Join(appsDir, f.Name()), Size: f.Size(), ModTime: f.ModTime()})
+		}
+	}
+	return apps, nil
+}
+
+// runApp runs an app from the apps dir in the background
+func (s *Server) runApp(w http.ResponseWriter, r *http.Request, params []string) {
+	req := new(runRequest)
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	apps, err := s.findApps(req.App)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(apps) == 0 {
+		appsDir, _, _ := s.settings()
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no flogo apps found containing name [%s] in apps dir [%s]", req.App, appsDir))
+		return
+	}
+	// An exact name wins, otherwise the latest app is run only if no name is given
+	selected := apps[0]
+	if req.App != "" && len(apps) > 1 {
+		selected = nil
+		var names []string
+		for _, a := range apps {
+			if a.Name == req.App {
+				selected = a
+			}
+			names = append(names, a.Name)
+		}
+		if selected == nil {
+			writeJSON(w, http.StatusConflict, map[string]interface{}{
+				"error":   fmt.Sprintf("%d apps found containing name [%s], use the full name", len(apps), req.App),
+				"matches": names,
+			})
+			return
+		}
+	}
+	opts := &app.RunOptions{LogLevel: req.LogLevel, Env: req.Env, Args: req.Args, Ports: req.Ports}
+	if opts.LogLevel == "" {
+		opts.LogLevel = config.LogLevelInfo
+	}
+	inst, cmd, err := app.Start(selected.Path, opts)
+	var portErr *app.PortInUseError
+	if errors.As(err, &portErr) {
+		body := map[string]interface{}{"error": err.Error(), "port": portErr.Port.Port}
+		if portErr.Holder != nil {
+			body["pid"] = portErr.Holder.PID
+		}
+		writeJSON(w, http.StatusConflict, body)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	go cmd.Wait()
+	writeJSON(w, http.StatusCreated, newProcessView(inst))
+}
+
+func (req *runRequest) validate() string {
+	switch req.LogLevel {
+	case "", config.LogLevelInfo, config.LogLevelDebug, config.LogLevelTrace:
+	default:
+		return fmt.Sprintf("unknown log level [%s], valid log levels are: %s, %s, %s", req.LogLevel, config.LogLevelInfo, config.LogLevelDebug, config.LogLevelTrace)
+	}
+	for _, e := range req.Env {
+		if !strings.Contains(e, "=") || strings.HasPrefix(e, "=") {
+			return fmt.Sprintf("environment variable [%s] must be in KEY=VALUE format", e)
+		}
+	}
+	for _, port := range req.Ports {
+		if port <= 0 || port > 65535 {
+			return fmt.Sprintf("port [%d] must be between 1 and 65535", port)
+		}
+	}
+	return ""
+}
+
+// listProcesses lists the apps started in the background, including the ones
+// which have exited
+func (s *Server) listProcesses(w http.ResponseWriter, r *http.Request, params []string) {
+	instances, err := process.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	views := []*processView{}
+	for _, inst := range instances {
+		views = append(views, newProcessView(inst))
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+func (s *Server) getProcess(w http.ResponseWriter, r *http.Request, params []string) {
+	if inst := findProcess(w, params[0]); inst != nil {
+		writeJSON(w, http.StatusOK, newProcessView(inst))
+	}
+}
+
+// stopProcess stops the app and removes it from the registry
+func (s *Server) stopProcess(w http.ResponseWriter, r *http.Request, params []string) {
+	inst := findProcess(w, params[0])
+	if inst == nil {
+		return
+	}
+	if err := process.Stop(inst, config.StopTimeout*time.Second); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := process.Remove(inst.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, newProcessView(inst))
+}
+
+func (s *Server) restartProcess(w http.ResponseWriter, r *http.Request, params []string) {
+	inst := findProcess(w, params[0])
+	if inst == nil {
+		return
+	}
+	cmd, err := process.Restart(inst, config.StopTimeout*time.Second)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	go cmd.Wait()
+	writeJSON(w, http.StatusOK, newProcessView(inst))
+}
+
+// processLogs returns the log of the app, the last lines if the lines query
+// param is set. With follow=true or an event stream accepted, the log is
+// streamed as server-sent events with a line per event.
+func (s *Server) processLogs(w http.ResponseWriter, r *http.Request, params []string) {
+	inst := findProcess(w, params[0])
+	if inst == nil {
+		return
+	}
+	lines := -1
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("lines [%s] must be a positive number", v))
+			return
+		}
+		lines = n
+	}
+	follow := r.URL.Query().Get("follow") == "true" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if !follow {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := process.Logs(r.Context(), inst.LogFile, lines, false, w); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	process.Logs(r.Context(), inst.LogFile, lines, true, &eventWriter{w: w, flusher: flusher})
+}
+
+// history returns the run history, the latest first
+func (s *Server) history(w http.ResponseWriter, r *http.Request, params []string) {
+	events, err := process.History()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if events == nil {
+		events = []*process.Event{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func findProcess(w http.ResponseWriter, query string) *process.Instance {
+	inst, err := process.Find(query)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return nil
+	}
+	return inst
+}
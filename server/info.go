@@ -0,0 +1,45 @@
+package server
+
+import (
+	"os"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/flogo"
+)
+
+// appInfo is the info about an app from its descriptor
+type appInfo struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Ports   []int  `json:"ports,omitempty"`
+}
+
+// cachedInfo is the info of an app binary with the size and mod time it was
+// read at, it is read again if the binary has changed
+type cachedInfo struct {
+	size    int64
+	modTime time.Time
+	info    *appInfo
+}
+
+// appInfo returns the info read from the app descriptor, nil if the app has
+// no descriptor
+func (s *Server) appInfo(a *appView) *appInfo {
+	s.infosMu.Lock()
+	cached, ok := s.infos[a.Path]
+	s.infosMu.Unlock()
+	if ok && cached.size == a.Size && cached.modTime.Equal(a.ModTime) {
+		return cached.info
+	}
+	var info *appInfo
+	if d, err := flogo.ReadDescriptor(a.Path); err == nil {
+		info = &appInfo{Name: d.Name, Version: d.Version}
+		for _, tp := range d.TriggerPorts(os.Environ()) {
+			info.Ports = append(info.Ports, tp.Port)
+		}
+	}
+	s.infosMu.Lock()
+	s.infos[a.Path] = &cachedInfo{size: a.Size, modTime: a.ModTime, info: info}
+	s.infosMu.Unlock()
+	return info
+}
@@ -0,0 +1,257 @@
+// Package server serves a local HTTP API to list, run and manage the flogo
+// apps, so that the IDE plugins and scripts do not have to run the program
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is the local API server
+type Server struct {
+	// mu guards the settings, which can be changed with the config API
+	mu         sync.RWMutex
+	appsDir    string
+	appPattern *regexp.Regexp
+	sortBy     string
+
+	// infos caches the info read from the app binaries, keyed by their path
+	infosMu sync.Mutex
+	infos   map[string]*cachedInfo
+
+	// token is required as a bearer token by the API if set
+	token string
+
+	http   *http.Server
+	routes []*route
+	// cancel ends the requests in progress like the log streams on shutdown
+	cancel context.CancelFunc
+}
+
+// route is a handler for a method and path, the * segments of the pattern
+// match any segment and are passed to the handler
+type route struct {
+	method  string
+	pattern []string
+	handler func(w http.ResponseWriter, r *http.Request, params []string)
+}
+
+// New returns a server for the apps in appsDir matching the pattern
+func New(appsDir string, pattern *regexp.Regexp, sortBy string) *Server {
+	s := &Server{appsDir: appsDir, appPattern: pattern, sortBy: sortBy, infos: map[string]*cachedInfo{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.http = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	s.routes = []*route{
+		{http.MethodGet, []string{""}, s.dashboard},
+		{http.MethodGet, []string{"api", "version"}, s.version},
+		{http.MethodGet, []string{"api", "apps"}, s.listApps},
+		{http.MethodPost, []string{"api", "apps", "run"}, s.runApp},
+		{http.MethodGet, []string{"api", "processes"}, s.listProcesses},
+		{http.MethodGet, []string{"api", "processes", "*"}, s.getProcess},
+		{http.MethodPost, []string{"api", "processes", "*", "stop"}, s.stopProcess},
+		{http.MethodPost, []string{"api", "processes", "*", "restart"}, s.restartProcess},
+		{http.MethodGet, []string{"api", "processes", "*", "logs"}, s.processLogs},
+		{http.MethodGet, []string{"api", "history"}, s.history},
+		{http.MethodGet, []string{"api", "config"}, s.getConfig},
+		{http.MethodPut, []string{"api", "config", "*"}, s.setConfig},
+		{http.MethodDelete, []string{"api", "config", "*"}, s.unsetConfig},
+	}
+	return s
+}
+
+// RequireToken makes the API require the token, with an Authorization:
+// Bearer header or the token query param, which is used by the dashboard to
+// follow the logs. The dashboard page itself is served without it.
+func (s *Server) RequireToken(token string) {
+	s.token = token
+}
+
+// WriteToken writes a new random token to the file at path, which only the
+// user can read
+func WriteToken(path string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	// The mode is not changed if the file exists
+	if err := f.Chmod(0600); err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(token + "\n"); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Listen returns a listener on the unix socket if set, otherwise on addr
+// which must be a loopback address as the API can run any app
+func Listen(addr, socket string) (net.Listener, error) {
+	if socket != "" {
+		// A socket left by a server which was not stopped cleanly is replaced
+		if _, err := os.Stat(socket); err == nil {
+			if conn, err := net.Dial("unix", socket); err == nil {
+				conn.Close()
+				return nil, fmt.Errorf("socket [%s] is in use by another server", socket)
+			}
+			os.Remove(socket)
+		}
+		return listenUnix(socket)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !isLoopback(host) {
+		return nil, fmt.Errorf("address [%s] is not a loopback address, the API can only be served on localhost", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// Serve serves the API on the listener until the server is shut down
+func (s *Server) Serve(l net.Listener) error {
+	err := s.http.Serve(l)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown ends the log streams and waits for the other requests to finish
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.cancel()
+	return s.http.Shutdown(ctx)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkLocal(r); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if segments[0] == "api" && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "missing or invalid token, see the output of the serve command")
+		return
+	}
+	var allowed []string
+	for _, rt := range s.routes {
+		params, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		if r.Method != rt.method && !(r.Method == http.MethodHead && rt.method == http.MethodGet) {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		rt.handler(w, r, params)
+		return
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed for [%s]", r.Method, r.URL.Path))
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("no API found at [%s]", r.URL.Path))
+}
+
+// authorized returns true if the request has the token, or if no token is
+// required
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// settings returns the apps dir, app pattern and sort strategy
+func (s *Server) settings() (string, *regexp.Regexp, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.appsDir, s.appPattern, s.sortBy
+}
+
+func (rt *route) match(segments []string) ([]string, bool) {
+	if len(segments) != len(rt.pattern) {
+		return nil, false
+	}
+	var params []string
+	for i, p := range rt.pattern {
+		if p == "*" {
+			params = append(params, segments[i])
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// checkLocal rejects the requests for another host, which a web page could
+// make with DNS rebinding, and the requests from web pages of other origins
+func checkLocal(r *http.Request) error {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host != "" && !isLoopback(host) {
+		return fmt.Errorf("host [%s] is not allowed, use localhost", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return fmt.Errorf("origin [%s] is not allowed", origin)
+		}
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
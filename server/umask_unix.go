@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package server
+
+import (
+	"net"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes the listens as the umask is shared by the whole process
+var umaskMu sync.Mutex
+
+// listenUnix listens on the socket created with the permissions 0600, the
+// umask is set before the socket is created so that no other user can
+// connect to it before it is restricted
+func listenUnix(socket string) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,75 @@
+package software
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+)
+
+// BackgroundCheck is a check for updates running while the app runs
+type BackgroundCheck struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartBackgroundCheck starts checking for updates unless the program runs
+// offline or the last check is more recent than the update check interval.
+// It returns nil if no check is started.
+func StartBackgroundCheck(appConfig *config.AppConfig) *BackgroundCheck {
+	if download.IsOffline() || !isUpdateCheckDue(appConfig.UpdateCheckInterval) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.UpdateCheckTimeout*time.Second)
+	bc := &BackgroundCheck{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(bc.done)
+		updateConfig, err := CheckForUpdates(ctx, NewReleaseSource(appConfig))
+		if err != nil {
+			return
+		}
+		WriteUpdateConfig(updateConfig)
+		recordUpdateCheck(time.Now())
+	}()
+	return bc
+}
+
+// Stop waits up to wait for the check to finish so that its result is saved,
+// and cancels it otherwise
+func (bc *BackgroundCheck) Stop(wait time.Duration) {
+	if bc == nil {
+		return
+	}
+	select {
+	case <-bc.done:
+	case <-time.After(wait):
+		bc.cancel()
+	}
+}
+
+// isUpdateCheckDue returns true if the last check for updates is older than the interval
+func isUpdateCheckDue(interval string) bool {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		d, _ = time.ParseDuration(config.DefaultUpdateCheckInterval)
+	}
+	state, err := config.ReadFile(config.StateFilePath())
+	if err != nil {
+		return true
+	}
+	last, _ := state["lastUpdateCheck"].(string)
+	t, err := time.Parse(time.RFC3339, last)
+	if err != nil {
+		return true
+	}
+	// A last check in the future means the clock was changed
+	return time.Since(t) >= d || t.After(time.Now())
+}
+
+// recordUpdateCheck saves the time of the last successful check for updates
+func recordUpdateCheck(t time.Time) {
+	config.UpdateState(func(m map[string]interface{}) {
+		m["lastUpdateCheck"] = t.UTC().Format(time.RFC3339)
+	})
+}
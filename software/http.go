@@ -0,0 +1,64 @@
+package software
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+)
+
+// cachedResponse is a response of the release API cached with its ETag
+type cachedResponse struct {
+	ETag string          `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
+
+// getJSON fetches the JSON document at url. The responses are cached with
+// their ETag so that the API is asked only if the document has changed,
+// which does not count against the rate limit of the GitHub API.
+func getJSON(ctx context.Context, url string) ([]byte, int, error) {
+	cachePath := filepath.Join(config.CacheDir(), config.ReleaseCacheFileName)
+	cache := map[string]*cachedResponse{}
+	if m, err := config.ReadFile(cachePath); err == nil {
+		b, _ := json.Marshal(m)
+		json.Unmarshal(b, &cache)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	cached := cache[url]
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	resp, err := download.Client().Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached.Body, http.StatusOK, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("bad status from [%s]: %s", url, resp.Status)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && json.Valid(body) {
+		err = config.Update(cachePath, func(m map[string]interface{}) {
+			m[url] = &cachedResponse{ETag: etag, Body: body}
+		})
+		if err != nil {
+			fmt.Printf("W> Unable to cache the release info: %s\n", err.Error())
+		}
+	}
+	return body, resp.StatusCode, nil
+}
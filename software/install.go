@@ -0,0 +1,227 @@
+package software
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+)
+
+// DefaultInstallDir returns the system wide dir where the program is installed by default
+func DefaultInstallDir() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return config.InstallPathLinux, nil
+	case "windows":
+		return config.InstallPathWindows, nil
+	case "darwin":
+		return config.InstallPathDarwin, nil
+	}
+	return "", fmt.Errorf("OS %s is not yet supported, please contact developer(s) to add support", runtime.GOOS)
+}
+
+// UserInstallDir returns the dir of the current user where the program is
+// installed with install --user, ~/.local/bin by default
+func UserInstallDir() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, config.InstallPathUserWindows)
+		}
+	}
+	return filepath.Join(config.GetUserHomeDir(), filepath.FromSlash(config.InstallPathUser))
+}
+
+// InstallDir returns the dir where the program was last installed, which is
+// recorded in the state file, or the default install dir
+func InstallDir() (string, error) {
+	state, err := config.ReadFile(config.StateFilePath())
+	if err == nil {
+		if path, _ := state["installPath"].(string); path != "" {
+			return filepath.Dir(path), nil
+		}
+	}
+	return DefaultInstallDir()
+}
+
+// InstalledPath returns the path where the program is installed
+func InstalledPath() (string, error) {
+	dir, err := InstallDir()
+	if err != nil {
+		return "", err
+	}
+	return installedPathIn(dir), nil
+}
+
+func installedPathIn(dir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(dir, config.AppName+".exe")
+	}
+	return filepath.Join(dir, config.AppName)
+}
+
+// writeInstallPath records the path of the installed program in the state file
+func writeInstallPath(path string) {
+	err := config.UpdateState(func(m map[string]interface{}) {
+		if path == "" {
+			delete(m, "installPath")
+			return
+		}
+		m["installPath"] = path
+	})
+	if err != nil {
+		fmt.Printf("E> Error ERR_WRITE_STATE: %s\n", err.Error())
+	}
+}
+
+// copyToInstallPath atomically replaces dst with the program at src. If the
+// install dir is not writable by the user it is retried with sudo on unix.
+func copyToInstallPath(src, dst string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err == nil {
+		err = replaceFile(src, dst)
+	}
+	if err == nil || !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("%s, run it as Administrator or install it with --user", err.Error())
+	}
+	if _, lookErr := exec.LookPath("sudo"); lookErr != nil {
+		return fmt.Errorf("%s, run it as root or install it with --user", err.Error())
+	}
+	fmt.Printf("\ni> No write permission for %s, retrying with sudo...", filepath.Dir(dst))
+	tmp := dst + ".tmp"
+	err = runSudo("cp", "-f", src, tmp)
+	if err == nil {
+		err = runSudo("chmod", "0755", tmp)
+	}
+	if err == nil {
+		err = runSudo("mv", "-f", tmp, dst)
+	}
+	return err
+}
+
+// removeInstalled removes the installed program at path, with sudo on unix
+// if the install dir is not writable by the user
+func removeInstalled(path string) error {
+	err := os.Remove(path)
+	if err == nil || !errors.Is(err, os.ErrPermission) || runtime.GOOS == "windows" {
+		return err
+	}
+	if _, lookErr := exec.LookPath("sudo"); lookErr != nil {
+		return err
+	}
+	return runSudo("rm", "-f", path)
+}
+
+func runSudo(args ...string) error {
+	cmd := exec.Command("sudo", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("sudo %s: %s: %s", strings.Join(args, " "), err.Error(), strings.TrimSpace(string(out)))
+	}
+	return err
+}
+
+// replaceFile copies src into a temp file next to dst and renames it over dst
+func replaceFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+config.AppName+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, in)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0755)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+		if err != nil && runtime.GOOS == "windows" {
+			// A running executable can not be replaced on windows but it
+			// can be renamed, so move it out of the way first
+			old := dst + ".old"
+			os.Remove(old)
+			if os.Rename(dst, old) == nil {
+				err = os.Rename(tmp.Name(), dst)
+			}
+		}
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// printPathAdvice prints how to add dir to the PATH if it is not in there,
+// and warns if another installation of the program shadows the one in dir
+func printPathAdvice(dir, installed string) {
+	if !inPath(dir) {
+		fmt.Printf("W> %s is not in your PATH, ", dir)
+		switch {
+		case runtime.GOOS == "windows":
+			fmt.Printf("add it with:\n   setx PATH \"%%PATH%%;%s\"\n", dir)
+		case filepath.Base(os.Getenv("SHELL")) == "fish":
+			fmt.Printf("add it with:\n   fish_add_path %s\n", dir)
+		default:
+			fmt.Printf("add it with:\n   echo 'export PATH=\"%s:$PATH\"' >> %s\n", dir, shellProfile())
+		}
+		fmt.Println("   and open a new terminal")
+		return
+	}
+	found, err := exec.LookPath(config.AppName)
+	if err != nil {
+		return
+	}
+	if !sameFile(found, installed) {
+		fmt.Printf("W> %s is found first in your PATH, remove it or move %s before it in your PATH\n", found, dir)
+	}
+}
+
+// shellProfile returns the startup file of the shell of the user
+func shellProfile() string {
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "zsh":
+		return "~/.zshrc"
+	case "bash":
+		return "~/.bashrc"
+	}
+	return "~/.profile"
+}
+
+func inPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if p != "" && sameFile(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameFile(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fa, fb)
+}
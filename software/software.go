@@ -2,19 +2,17 @@ package software
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/abhijitWakchaure/run-flogo-app/config"
-	"github.com/spf13/viper"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
 )
 
 // UpdateConfig ...
@@ -22,10 +20,16 @@ type UpdateConfig struct {
 	IsUpdateAvailable bool   `json:"isUpdateAvailable"`
 	UpdateURL         string `json:"updateURL"`
 	ReleaseNotes      string `json:"releaseNotes"`
+	LatestVersion     string `json:"latestVersion"`
+	ChecksumsURL      string `json:"checksumsURL,omitempty"`
+	SignatureURL      string `json:"signatureURL,omitempty"`
 }
 
-// Install will install the program
-func Install(src string) {
+// Install will install the program into dir, or where it was last installed
+// if dir is empty. The version being replaced is kept in the versions dir so
+// that it can be restored with rollback. It returns the kept version, which
+// is nil if nothing was kept.
+func Install(src, dir string, keepVersions int) *KeptVersion {
 	fmt.Print("#> Installing run-flogo-app...")
 	if src == "" {
 		ex, err := os.Executable()
@@ -41,140 +45,250 @@ func Install(src string) {
 			os.Exit(1)
 		}
 	}
-	err := os.Chmod(src, 0777)
-	if err != nil {
-		fmt.Println("failed")
-		fmt.Printf("\n# Error: ERR_INSTALL_MAKEEXECUTABLE %s\n", err.Error())
-		os.Exit(1)
+	var err error
+	if dir == "" {
+		dir, err = InstallDir()
+		if err != nil {
+			fmt.Printf("\nError: %s\n", err.Error())
+			os.Exit(1)
+		}
 	}
-	var dst string
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "linux":
-		dst = filepath.Join(config.InstallPathLinux, config.AppName)
-		cmd = exec.Command("sudo", "cp", "-fpv", src, dst)
-	case "windows":
-		dst = filepath.Join(config.InstallPathWindows, config.AppName+".exe")
-		cmd = exec.Command("cmd.exe", "/C", `copy /Y `+src+" "+dst)
-	case "darwin":
-		dst = filepath.Join(config.InstallPathDarwin, config.AppName)
-		cmd = exec.Command("sudo", "cp", "-fpv", src, dst)
-	default:
-		fmt.Printf("\nError: OS %s is not yet supported, please contact developer(s) to add support\n", runtime.GOOS)
-		os.Exit(1)
+	dir, _ = filepath.Abs(dir)
+	dst := installedPathIn(dir)
+	if sameFile(src, dst) {
+		fmt.Println("done")
+		fmt.Printf("#> %s is already installed at %s\n", config.AppName, dst)
+		writeInstallPath(dst)
+		return nil
 	}
-	err = cmd.Run()
+	var kept *KeptVersion
+	if keepVersions > 0 {
+		kept, err = saveInstalled(dst)
+		if err != nil {
+			fmt.Printf("\nW> Unable to keep the installed version for rollback: %s\n", err.Error())
+		}
+	}
+	err = copyToInstallPath(src, dst)
 	if err != nil {
 		fmt.Println("failed")
 		fmt.Printf("\n# Error: ERR_INSTALL_COPY %s\n", err)
 		os.Exit(1)
 	}
 	fmt.Println("done")
+	writeInstallPath(dst)
+	if kept != nil {
+		fmt.Printf("#> Kept the previous version %s, run %s rollback to restore it\n", kept.Version, config.AppName)
+	}
+	if err := pruneVersions(keepVersions); err != nil {
+		fmt.Printf("W> Unable to remove the old versions: %s\n", err.Error())
+	}
+	fmt.Printf("#> Installed at %s\n", dst)
+	printPathAdvice(dir, dst)
 	fmt.Println("#> You can now directly execute", config.AppName)
 	WriteUpdateConfig(nil)
+	return kept
 }
 
-// Uninstall will install the program
-func Uninstall(installPath string) {
+// Uninstall will uninstall the program from where it was installed, along
+// with its default config file and the state dir which has the versions kept
+// for rollback. A config file set with --config or RUN_FLOGO_APP_CONFIG is
+// owned by the user and is kept.
+func Uninstall() {
 	fmt.Println("#> Uninstalling run-flogo-app...")
-	fmt.Printf("   Deleting config file...")
-	userHome := config.GetUserHomeDir()
-	os.Remove(filepath.Join(userHome, config.ConfigFileName))
-	fmt.Printf("\n   Deleting main executable...")
-	var target string
-	if runtime.GOOS == "windows" {
-		target = installPath + string(os.PathSeparator) + config.AppName + ".exe"
-	} else {
-		target = filepath.Join(installPath, config.AppName)
-	}
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "linux":
-		cmd = exec.Command("sudo", "rm", target)
-	case "windows":
-		cmd = exec.Command("cmd.exe", "/C", `del `+target)
-	case "darwin":
-		cmd = exec.Command("sudo", "rm", target)
-	default:
-		fmt.Printf("\nError: OS %s is not yet supported, please contact developers\n", runtime.GOOS)
+	target, err := InstalledPath()
+	if err != nil {
+		fmt.Printf("\nError: %s\n", err.Error())
 		os.Exit(1)
 	}
-	err := cmd.Run()
-	if err != nil {
+	fmt.Printf("   Deleting config file...")
+	settings := config.DefaultSettingsFilePath()
+	os.Remove(settings)
+	os.Remove(settings + ".lock")
+	os.RemoveAll(config.StateDir())
+	if path := config.SettingsFilePath(); path != settings {
+		fmt.Printf("\n   Keeping config file [%s]", path)
+	}
+	fmt.Printf("\n   Deleting main executable...")
+	err = removeInstalled(target)
+	if err != nil && !os.IsNotExist(err) {
 		fmt.Println("failed")
-		fmt.Println("#> Unable to uninstall run-flogo-app! Error ERR_UNINSTALL_REMOVE...you can manually delete", filepath.Join(installPath, config.AppName))
+		fmt.Printf("#> Unable to uninstall run-flogo-app! Error ERR_UNINSTALL_REMOVE %s...you can manually delete %s\n", err.Error(), target)
 		os.Exit(1)
 	}
-	fmt.Printf("\n#> Finished uninstalling run-flogo-app")
+	fmt.Printf("\n#> Finished uninstalling run-flogo-app\n")
 }
 
-// Update will update the app
-func Update(appConfig *config.AppConfig) {
-	updateConfig, err := CheckForUpdates()
+// Update will update the app to the release selected by the source
+func Update(appConfig *config.AppConfig, src *ReleaseSource) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.UpdateCheckTimeout*time.Second)
+	updateConfig, err := CheckForUpdates(ctx, src)
+	cancel()
 	if err != nil {
-		fmt.Printf("\nFailed to check for updates due to:\n%s", err.Error())
+		fmt.Printf("\nFailed to check for updates due to:\n%s\n", err.Error())
 		os.Exit(1)
 	}
-	if updateConfig == nil || len(updateConfig.UpdateURL) <= 2 {
-		fmt.Println("Your app is up to date 👍")
-		WriteUpdateConfig(nil)
+	recordUpdateCheck(time.Now())
+	if !updateConfig.IsUpdateAvailable {
+		status := GetVersionStatus(config.VERSION, updateConfig.LatestVersion)
+		if src.Version == "" {
+			WriteUpdateConfig(updateConfig)
+		}
+		if status == VersionDevBuild {
+			fmt.Printf("%s, use --version to install a release\n", status.Message(updateConfig.LatestVersion))
+		} else if status == VersionUpToDate || status == VersionAhead {
+			fmt.Println(status.Message(updateConfig.LatestVersion))
+		} else {
+			fmt.Printf("No %s_%s binary found in the release %s\n", runtime.GOOS, runtime.GOARCH, updateConfig.LatestVersion)
+		}
 		os.Exit(0)
 	}
-	WriteUpdateConfig(updateConfig)
-	binaryName := filepath.Base(updateConfig.UpdateURL)
-	downloadPath := filepath.Join(appConfig.AppsDir, binaryName)
-	fmt.Printf("Downloading latest version from: %s\n\n", updateConfig.UpdateURL)
-	err = DownloadFile(downloadPath, updateConfig.UpdateURL)
+	// A pinned version may be older, it is not stored as an available update
+	if src.Version == "" {
+		WriteUpdateConfig(updateConfig)
+	}
+	downloadPath, err := downloadRelease(updateConfig, appConfig.AppsDir, config.ReleasePublicKey, src.AllowUnsigned)
+	if err != nil {
+		fmt.Printf("\nE> Error %s\n", err.Error())
+		os.Exit(1)
+	}
+	// The previous version is kept until the update is verified so that it can
+	// be rolled back, even if no versions are to be kept
+	keep := appConfig.KeepVersions
+	if keep < 1 {
+		keep = 1
+	}
+	kept := Install(downloadPath, "", keep)
+	dst, _ := InstalledPath()
+	err = selfCheck(dst)
+	if err == nil {
+		if appConfig.KeepVersions < 1 && kept != nil {
+			if err := pruneVersions(0); err != nil {
+				fmt.Printf("W> Unable to remove the old versions: %s\n", err.Error())
+			} else {
+				fmt.Printf("#> Removed the previous version %s as keepVersions is %d\n", kept.Version, appConfig.KeepVersions)
+			}
+		}
+		return
+	}
+	fmt.Printf("\nE> Error ERR_UPDATE_SELFCHECK: the installed update does not work: %s\n", err.Error())
+	if kept == nil {
+		fmt.Println("#> No previous version is kept to roll back to, please reinstall the program")
+		os.Exit(1)
+	}
+	fmt.Printf("#> Rolling back to %s...", kept.Version)
+	err = copyToInstallPath(kept.Path, dst)
 	if err != nil {
-		fmt.Printf("\nFailed to download updated app due to:\n%s", err.Error())
+		fmt.Println("failed")
+		fmt.Printf("\nE> Error ERR_UPDATE_ROLLBACK: %s\n", err.Error())
 		os.Exit(1)
 	}
-	Install(downloadPath)
+	fmt.Println("done")
+	os.Exit(1)
 }
 
-// CheckForUpdates will check for latest release
-func CheckForUpdates() (*UpdateConfig, error) {
-	resp, err := http.Get(config.GithubLastestReleaseURL)
+// downloadRelease downloads the binary of the update into dir and verifies it
+// with the signed checksum manifest of the release. A release without the
+// signed manifest, or a build without the public key, is refused unless
+// allowUnsigned is set, the checksum is then verified if the manifest exists.
+func downloadRelease(updateConfig *UpdateConfig, dir, publicKey string, allowUnsigned bool) (string, error) {
+	signed := updateConfig.ChecksumsURL != "" && updateConfig.SignatureURL != ""
+	switch {
+	case !signed && !allowUnsigned:
+		return "", fmt.Errorf("ERR_UPDATE_NOCHECKSUM: the release %s does not have the checksum manifest [%s] and its signature [%s], refusing to install it, use --allow-unsigned to install it anyway",
+			updateConfig.LatestVersion, config.ChecksumsAssetName, config.SignatureAssetName)
+	case publicKey == "" && !allowUnsigned:
+		return "", fmt.Errorf("ERR_UPDATE_NOKEY: this build of %s has no release public key to verify the signature, use --allow-unsigned to verify the checksum only", config.AppName)
+	}
+	var manifest, signature []byte
+	var err error
+	if updateConfig.ChecksumsURL != "" {
+		manifest, err = fetchSmallFile(updateConfig.ChecksumsURL)
+	}
+	if err == nil && signed && publicKey != "" {
+		signature, err = fetchSmallFile(updateConfig.SignatureURL)
+	}
 	if err != nil {
-		err = fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_HTTPGET %s", err)
-		fmt.Printf("\n\n%s\n", err)
-		return nil, err
+		return "", fmt.Errorf("ERR_UPDATE_NOCHECKSUM: failed to download the checksum manifest due to: %s", err.Error())
 	}
-	defer resp.Body.Close()
-	var gitdata map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&gitdata)
+	binaryName := filepath.Base(updateConfig.UpdateURL)
+	downloadPath := filepath.Join(dir, binaryName)
+	fmt.Printf("Downloading latest version from: %s\n\n", updateConfig.UpdateURL)
+	err = DownloadFile(downloadPath, updateConfig.UpdateURL)
+	if err != nil {
+		return "", fmt.Errorf("ERR_UPDATE_DOWNLOAD: failed to download updated app due to: %s", err.Error())
+	}
+	switch {
+	case signature != nil:
+		err = VerifyRelease(downloadPath, binaryName, updateConfig.LatestVersion, manifest, signature, publicKey)
+	case manifest != nil:
+		err = VerifyChecksum(downloadPath, binaryName, manifest)
+	}
+	if err != nil {
+		os.Remove(downloadPath)
+		return "", fmt.Errorf("ERR_UPDATE_VERIFY: %s, the downloaded file is deleted and the update is not installed", err.Error())
+	}
+	switch {
+	case signature != nil:
+		fmt.Println("#> Verified the checksum and the signature of the downloaded app")
+	case manifest != nil:
+		fmt.Println("W> Verified the checksum of the downloaded app without its signature, as --allow-unsigned is set")
+	default:
+		fmt.Printf("W> The release %s does not have a checksum manifest, it is installed without verification as --allow-unsigned is set\n", updateConfig.LatestVersion)
+	}
+	return downloadPath, nil
+}
+
+// CheckForUpdates will check for the release selected by the source. An
+// update is available only if the release is newer than the running version,
+// unless the source pins a version which is then installed even if older.
+func CheckForUpdates(ctx context.Context, src *ReleaseSource) (*UpdateConfig, error) {
+	gitdata, err := src.fetchRelease(ctx)
 	if err != nil {
-		err = fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_DECODE %s", err)
-		fmt.Printf("\n\n%s\n", err)
-		fmt.Printf("\nPlease create an issue here for this error: %s\n\n", config.GithubIssuesURL)
 		return nil, err
 	}
 	assets, ok := gitdata["assets"].([]interface{})
 	if !ok {
-		err = fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_NOASSETS %s", err)
-		fmt.Printf("\n\n%s\n", err)
-		return nil, err
+		return nil, fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_NOASSETS no assets found in the release at [%s]", src.API)
 	}
+	var checksumsURL, signatureURL, binaryURL string
 	OSAndArch := fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH)
 	for _, d := range assets {
-		durl := d.(map[string]interface{})["browser_download_url"].(string)
-		if !strings.Contains(durl, OSAndArch) {
+		durl, _ := d.(map[string]interface{})["browser_download_url"].(string)
+		if !isHTTPS(durl) {
 			continue
 		}
-		if strings.Contains(durl, config.VERSION) {
-			// fmt.Println()
-			// fmt.Println("Your app is up to date 👍")
-			WriteUpdateConfig(nil)
-			return nil, nil
+		switch filepath.Base(durl) {
+		case config.ChecksumsAssetName:
+			checksumsURL = durl
+		case config.SignatureAssetName:
+			signatureURL = durl
+		default:
+			if binaryURL == "" && strings.Contains(durl, OSAndArch) {
+				binaryURL = durl
+			}
 		}
-		return &UpdateConfig{
-			IsUpdateAvailable: true,
-			UpdateURL:         durl,
-			ReleaseNotes:      strings.Replace(strings.TrimSpace(gitdata["body"].(string)), "\n", "\n\t", -1),
-		}, nil
-
 	}
-	return nil, nil
+	tag, _ := gitdata["tag_name"].(string)
+	updateConfig := &UpdateConfig{
+		LatestVersion: releaseVersion(tag, binaryURL),
+	}
+	status := GetVersionStatus(config.VERSION, updateConfig.LatestVersion)
+	if src.Version == "" && status != VersionOutdated {
+		return updateConfig, nil
+	}
+	if src.Version != "" && status == VersionUpToDate {
+		return updateConfig, nil
+	}
+	if binaryURL == "" {
+		return updateConfig, nil
+	}
+	body, _ := gitdata["body"].(string)
+	updateConfig.IsUpdateAvailable = true
+	updateConfig.UpdateURL = binaryURL
+	updateConfig.ReleaseNotes = strings.Replace(strings.TrimSpace(body), "\n", "\n\t", -1)
+	updateConfig.ChecksumsURL = checksumsURL
+	updateConfig.SignatureURL = signatureURL
+	return updateConfig, nil
 }
 
 // WriteUpdateConfig will write the update info
@@ -186,10 +300,29 @@ func WriteUpdateConfig(updateConfig *UpdateConfig) {
 			ReleaseNotes:      "",
 		}
 	}
-	viper.Set("isUpdateAvailable", updateConfig.IsUpdateAvailable)
-	viper.Set("updateURL", updateConfig.UpdateURL)
-	viper.Set("releaseNotes", updateConfig.ReleaseNotes)
-	viper.WriteConfig()
+	err := config.UpdateState(func(m map[string]interface{}) {
+		m["isUpdateAvailable"] = updateConfig.IsUpdateAvailable
+		m["updateURL"] = updateConfig.UpdateURL
+		m["releaseNotes"] = updateConfig.ReleaseNotes
+		m["latestVersion"] = updateConfig.LatestVersion
+	})
+	if err != nil {
+		fmt.Printf("E> Error ERR_WRITE_STATE: %s\n", err.Error())
+	}
+}
+
+// ReadUpdateConfig will read the update info from the state file
+func ReadUpdateConfig() *UpdateConfig {
+	updateConfig := new(UpdateConfig)
+	state, err := config.ReadFile(config.StateFilePath())
+	if err != nil {
+		return updateConfig
+	}
+	updateConfig.IsUpdateAvailable, _ = state["isUpdateAvailable"].(bool)
+	updateConfig.UpdateURL, _ = state["updateURL"].(string)
+	updateConfig.ReleaseNotes, _ = state["releaseNotes"].(string)
+	updateConfig.LatestVersion, _ = state["latestVersion"].(string)
+	return updateConfig
 }
 
 // PrintUpdateInfo will print the update info
@@ -197,17 +330,19 @@ func PrintUpdateInfo(updateConfig *UpdateConfig) {
 	if updateConfig == nil {
 		return
 	}
-	if updateConfig.IsUpdateAvailable {
-		fmt.Println("#> New version of the app is available!")
+	if updateConfig.IsUpdateAvailable && GetVersionStatus(config.VERSION, updateConfig.LatestVersion) == VersionOutdated {
+		fmt.Printf("#> %s\n", VersionOutdated.Message(updateConfig.LatestVersion))
 		fmt.Println("#> Release Notes:")
 		fmt.Printf("\t%s\n\n", updateConfig.ReleaseNotes)
 	}
 }
 
+// stdin is shared by the prompts so that no input buffered by one is lost
+var stdin = bufio.NewReader(os.Stdin)
+
 // HandleYNInput handles the Yes/No input
 func HandleYNInput() bool {
-	reader := bufio.NewReader(os.Stdin)
-	inputBytes, _, err := reader.ReadLine()
+	inputBytes, _, err := stdin.ReadLine()
 	if err != nil {
 		fmt.Printf("\nE> Error ERR_READ_USRIN: %s\n", err.Error())
 	}
@@ -227,8 +362,7 @@ func HandleYNInput() bool {
 
 // HandleNumericInput handles the numeric input
 func HandleNumericInput() int {
-	reader := bufio.NewReader(os.Stdin)
-	inputBytes, _, err := reader.ReadLine()
+	inputBytes, _, err := stdin.ReadLine()
 	if err != nil {
 		fmt.Printf("\nE> Error ERR_READ_USRIN: %s\n", err.Error())
 		os.Exit(1)
@@ -243,24 +377,7 @@ func HandleNumericInput() int {
 }
 
 // DownloadFile will download the file specified by the URL and store it at the
-// location specified
+// location specified, an interrupted download is resumed on the next call
 func DownloadFile(filepath string, url string) (err error) {
-	out, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
-	}
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return err
-	}
-	return nil
+	return download.File(context.Background(), url, filepath, nil)
 }
@@ -0,0 +1,111 @@
+package software
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/semver"
+)
+
+// ReleaseSource decides where and which release is looked up for the updates
+type ReleaseSource struct {
+	// API is the base URL of a GitHub compatible releases API for the repo
+	API string
+	// Channel is either stable or prerelease
+	Channel string
+	// Version pins the release to the tag, latest release of the channel is used if empty
+	Version string
+	// AllowUnsigned installs the release even if it is not signed
+	AllowUnsigned bool
+}
+
+// NewReleaseSource returns the release source from the app config, the API
+// can be overridden with the RUN_FLOGO_APP_RELEASE_API env variable
+func NewReleaseSource(appConfig *config.AppConfig) *ReleaseSource {
+	src := &ReleaseSource{
+		API:     appConfig.ReleaseAPI,
+		Channel: appConfig.UpdateChannel,
+	}
+	if api := os.Getenv(config.EnvReleaseAPI); api != "" {
+		src.API = api
+	}
+	if src.API == "" {
+		src.API = config.DefaultReleaseAPI
+	}
+	if src.Channel == "" {
+		src.Channel = config.UpdateChannelStable
+	}
+	return src
+}
+
+// isHTTPS returns true if the URL uses https, the releases are only looked up
+// and downloaded over https
+func isHTTPS(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+// releaseURL returns the URL of the release or the list of releases to fetch
+func (src *ReleaseSource) releaseURL() string {
+	api := strings.TrimSuffix(src.API, "/")
+	switch {
+	case src.Version != "":
+		return api + "/releases/tags/" + url.PathEscape(src.Version)
+	case src.Channel == config.UpdateChannelPrerelease:
+		return api + "/releases"
+	default:
+		return api + "/releases/latest"
+	}
+}
+
+// fetchRelease returns the release data as returned by the GitHub API
+func (src *ReleaseSource) fetchRelease(ctx context.Context) (map[string]interface{}, error) {
+	if !isHTTPS(src.API) {
+		return nil, fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_INSECURE the release API [%s] must be an https URL", src.API)
+	}
+	releaseURL := src.releaseURL()
+	body, status, err := getJSON(ctx, releaseURL)
+	if status == http.StatusNotFound && src.Version != "" {
+		return nil, fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_NOVERSION no release found with version [%s] at [%s]", src.Version, src.API)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_HTTPGET %s", err)
+	}
+	if src.Version == "" && src.Channel == config.UpdateChannelPrerelease {
+		var releases []map[string]interface{}
+		err = json.Unmarshal(body, &releases)
+		if err != nil {
+			return nil, fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_DECODE %s", err)
+		}
+		// Pick the highest version as the list is ordered by the creation date
+		var latest map[string]interface{}
+		var latestVersion *semver.Version
+		for _, r := range releases {
+			if draft, _ := r["draft"].(bool); draft {
+				continue
+			}
+			tag, _ := r["tag_name"].(string)
+			v, err := semver.Parse(tag)
+			if latest == nil || (err == nil && (latestVersion == nil || v.Compare(latestVersion) > 0)) {
+				latest, latestVersion = r, v
+			}
+		}
+		if latest == nil {
+			return nil, errors.New("E> run-flogo-app Error: ERR_CHKUPDATE_NORELEASE no releases found at " + src.API)
+		}
+		return latest, nil
+	}
+	var release map[string]interface{}
+	err = json.Unmarshal(body, &release)
+	if err != nil {
+		return nil, fmt.Errorf("E> run-flogo-app Error: ERR_CHKUPDATE_DECODE %s", err)
+	}
+	return release, nil
+}
@@ -0,0 +1,226 @@
+package software
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+)
+
+var binaryName = fmt.Sprintf("run-flogo-app-%s_%s", runtime.GOOS, runtime.GOARCH)
+
+// newKey returns a new ed25519 key with its public key in the format of
+// config.ReleasePublicKey
+func newKey(t *testing.T) (ed25519.PrivateKey, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, base64.StdEncoding.EncodeToString(pub)
+}
+
+// TestMain trusts the certificate of the https test servers, which is the same
+// for all of them, before the client of the downloads is created
+func TestMain(m *testing.M) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	dir, err := os.MkdirTemp("", "software-test")
+	if err != nil {
+		panic(err)
+	}
+	caBundle := filepath.Join(dir, "ca.pem")
+	err = os.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0644)
+	srv.Close()
+	if err != nil {
+		panic(err)
+	}
+	download.SetCABundle(caBundle)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func manifestOf(version, name, content string) []byte {
+	sum := sha256.Sum256([]byte(content))
+	return []byte(fmt.Sprintf("# version: %s\n%s  other-linux_arm64\n%s *%s\n", version, strings.Repeat("0", 64), hex.EncodeToString(sum[:]), name))
+}
+
+func TestVerifyRelease(t *testing.T) {
+	priv, pub := newKey(t)
+	other, _ := newKey(t)
+	dir := t.TempDir()
+	binary := filepath.Join(dir, binaryName)
+	if err := os.WriteFile(binary, []byte("binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := manifestOf("v2.3.0", binaryName, "binary")
+	invalid := []byte("# version: v2.3.0\nnot-a-checksum *" + binaryName + "\n")
+	tests := []struct {
+		name      string
+		manifest  []byte
+		signature []byte
+		publicKey string
+		err       string
+	}{
+		{"good signature", manifest, ed25519.Sign(priv, manifest), pub, ""},
+		{"base64 signature", manifest, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, manifest)) + "\n"), pub, ""},
+		{"bad signature", manifest, ed25519.Sign(other, manifest), pub, "signature"},
+		{"changed manifest", manifestOf("v2.3.0", binaryName, "other"), ed25519.Sign(priv, manifest), pub, "signature"},
+		{"checksum mismatch", manifestOf("v2.3.0", binaryName, "other"), ed25519.Sign(priv, manifestOf("v2.3.0", binaryName, "other")), pub, "SHA-256"},
+		{"missing entry", manifestOf("v2.3.0", "other", "binary"), ed25519.Sign(priv, manifestOf("v2.3.0", "other", "binary")), pub, "no checksum"},
+		{"invalid checksum", invalid, ed25519.Sign(priv, invalid), pub, "invalid checksum"},
+		{"other version", manifestOf("v2.2.0", binaryName, "binary"), ed25519.Sign(priv, manifestOf("v2.2.0", binaryName, "binary")), pub, "version v2.2.0"},
+		{"missing version", manifest[strings.IndexByte(string(manifest), '\n')+1:], ed25519.Sign(priv, manifest[strings.IndexByte(string(manifest), '\n')+1:]), pub, "no version"},
+		{"missing signature", manifest, nil, pub, "signature"},
+		{"invalid key", manifest, ed25519.Sign(priv, manifest), "", "public key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyRelease(binary, binaryName, "v2.3.0", tt.manifest, tt.signature, tt.publicKey)
+			if tt.err == "" && err != nil {
+				t.Fatalf("valid release is refused: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("error is %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// release is a release served by the fake releases API
+type release struct {
+	tag       string
+	binary    string
+	manifest  []byte
+	signature []byte
+}
+
+// newReleaseAPI serves the release like the GitHub releases API, with only
+// the assets which are set
+func newReleaseAPI(t *testing.T, r *release) (*httptest.Server, *int) {
+	t.Helper()
+	downloads := 0
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/releases/tags/" + r.tag:
+			assets := []map[string]string{{"browser_download_url": srv.URL + "/download/" + binaryName}}
+			if r.manifest != nil {
+				assets = append(assets, map[string]string{"browser_download_url": srv.URL + "/download/" + config.ChecksumsAssetName})
+			}
+			if r.signature != nil {
+				assets = append(assets, map[string]string{"browser_download_url": srv.URL + "/download/" + config.SignatureAssetName})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"tag_name": r.tag, "body": "notes", "assets": assets})
+		case "/download/" + binaryName:
+			downloads++
+			w.Write([]byte(r.binary))
+		case "/download/" + config.ChecksumsAssetName:
+			w.Write(r.manifest)
+		case "/download/" + config.SignatureAssetName:
+			w.Write(r.signature)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &downloads
+}
+
+func TestUpdateDownload(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	version := config.VERSION
+	config.VERSION = "v2.2.1"
+	defer func() { config.VERSION = version }()
+
+	priv, pub := newKey(t)
+	other, _ := newKey(t)
+	manifest := manifestOf("v2.3.0", binaryName, "binary")
+	old := manifestOf("v2.2.0", binaryName, "binary")
+	tests := []struct {
+		name          string
+		release       *release
+		publicKey     string
+		allowUnsigned bool
+		err           string
+		downloaded    bool
+	}{
+		{"good signature", &release{"v2.3.0", "binary", manifest, ed25519.Sign(priv, manifest)}, pub, false, "", true},
+		{"bad signature", &release{"v2.3.0", "binary", manifest, ed25519.Sign(other, manifest)}, pub, false, "ERR_UPDATE_VERIFY", true},
+		{"checksum mismatch", &release{"v2.3.0", "tampered", manifest, ed25519.Sign(priv, manifest)}, pub, false, "ERR_UPDATE_VERIFY", true},
+		{"older signed release", &release{"v2.4.0", "binary", old, ed25519.Sign(priv, old)}, pub, false, "ERR_UPDATE_VERIFY", true},
+		{"missing manifest", &release{"v2.3.0", "binary", nil, nil}, pub, false, "ERR_UPDATE_NOCHECKSUM", false},
+		{"missing manifest of old tag", &release{"v2.2.0", "binary", nil, nil}, pub, false, "ERR_UPDATE_NOCHECKSUM", false},
+		{"missing signature", &release{"v2.3.0", "binary", manifest, nil}, pub, false, "ERR_UPDATE_NOCHECKSUM", false},
+		{"missing manifest allowed", &release{"v2.3.0", "binary", nil, nil}, pub, true, "", true},
+		{"no public key", &release{"v2.3.0", "binary", manifest, ed25519.Sign(priv, manifest)}, "", false, "ERR_UPDATE_NOKEY", false},
+		{"no public key allowed", &release{"v2.3.0", "binary", manifest, ed25519.Sign(priv, manifest)}, "", true, "", true},
+		{"no public key checksum mismatch", &release{"v2.3.0", "tampered", manifest, ed25519.Sign(priv, manifest)}, "", true, "ERR_UPDATE_VERIFY", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, downloads := newReleaseAPI(t, tt.release)
+			src := &ReleaseSource{API: srv.URL, Channel: config.UpdateChannelStable, Version: tt.release.tag, AllowUnsigned: tt.allowUnsigned}
+			updateConfig, err := CheckForUpdates(context.Background(), src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !updateConfig.IsUpdateAvailable || updateConfig.LatestVersion != tt.release.tag {
+				t.Fatalf("release is not available: %+v", updateConfig)
+			}
+			dir := t.TempDir()
+			path, err := downloadRelease(updateConfig, dir, tt.publicKey, src.AllowUnsigned)
+			if (*downloads > 0) != tt.downloaded {
+				t.Errorf("binary is downloaded %d times", *downloads)
+			}
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Fatalf("error is %v, want %s", err, tt.err)
+				}
+				if _, err := os.Stat(filepath.Join(dir, binaryName)); !os.IsNotExist(err) {
+					t.Error("refused download is kept")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("release is refused: %v", err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.release.binary {
+				t.Errorf("downloaded binary has %q", data)
+			}
+		})
+	}
+}
+
+func TestInsecureReleaseAPI(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+	for _, api := range []string{srv.URL, "file:///tmp/releases"} {
+		_, err := CheckForUpdates(context.Background(), &ReleaseSource{API: api, Channel: config.UpdateChannelStable})
+		if err == nil || !strings.Contains(err.Error(), "ERR_CHKUPDATE_INSECURE") {
+			t.Errorf("release API [%s] is not refused: %v", api, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("insecure release API got %d requests", requests)
+	}
+}
@@ -0,0 +1,125 @@
+package software
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+	"github.com/abhijitWakchaure/run-flogo-app/semver"
+)
+
+// maxManifestSize limits the size of the downloaded checksum manifest and signature
+const maxManifestSize = 1 << 20
+
+// manifestVersionPrefix starts the line of the manifest with the version of the release
+const manifestVersionPrefix = "# version:"
+
+// VerifyRelease verifies the signature of the checksum manifest with the
+// public key, that the manifest is of the version being installed so that an
+// older signed release can not be served instead, and then the SHA-256 of the
+// downloaded binary against the entry for binaryName in the manifest
+func VerifyRelease(binaryPath, binaryName, version string, manifest, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid release public key")
+	}
+	sig := decodeSignature(signature)
+	if !ed25519.Verify(ed25519.PublicKey(key), manifest, sig) {
+		return errors.New("signature of the checksum manifest does not match")
+	}
+	err = checkManifestVersion(manifest, version)
+	if err != nil {
+		return err
+	}
+	return VerifyChecksum(binaryPath, binaryName, manifest)
+}
+
+// VerifyChecksum verifies the SHA-256 of the downloaded binary against the
+// entry for binaryName in the manifest, without checking its signature
+func VerifyChecksum(binaryPath, binaryName string, manifest []byte) error {
+	expected, err := findChecksum(manifest, binaryName)
+	if err != nil {
+		return err
+	}
+	actual, err := download.SHA256File(binaryPath)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(expected, actual) {
+		return fmt.Errorf("SHA-256 of [%s] is %s but the checksum manifest has %s", binaryName, actual, expected)
+	}
+	return nil
+}
+
+// checkManifestVersion checks the version in the "# version: <version>" line
+// which build.sh writes at the top of the manifest
+func checkManifestVersion(manifest []byte, version string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, manifestVersionPrefix) {
+			continue
+		}
+		found := strings.TrimSpace(strings.TrimPrefix(line, manifestVersionPrefix))
+		v, err := semver.Parse(found)
+		expected, eerr := semver.Parse(version)
+		if err != nil || eerr != nil || v.Compare(expected) != 0 {
+			return fmt.Errorf("checksum manifest is of the version %s, not of the release %s", found, version)
+		}
+		return nil
+	}
+	return fmt.Errorf("no version found in the checksum manifest of the release %s", version)
+}
+
+// decodeSignature accepts the raw signature or the base64 encoded one
+func decodeSignature(signature []byte) []byte {
+	if len(signature) == ed25519.SignatureSize {
+		return signature
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil {
+		return signature
+	}
+	return sig
+}
+
+// findChecksum returns the checksum of the file from a manifest in the sha256sum format
+func findChecksum(manifest []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks the files read in binary mode with a '*'
+		if strings.TrimPrefix(fields[1], "*") == name {
+			if _, err := hex.DecodeString(fields[0]); err != nil || len(fields[0]) != sha256.Size*2 {
+				return "", fmt.Errorf("invalid checksum for [%s] in the checksum manifest", name)
+			}
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found for [%s] in the checksum manifest", name)
+}
+
+// fetchSmallFile downloads a small file like the checksum manifest into memory
+func fetchSmallFile(url string) ([]byte, error) {
+	resp, err := download.Client().Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+}
@@ -0,0 +1,82 @@
+package software
+
+import (
+	"fmt"
+
+	"github.com/abhijitWakchaure/run-flogo-app/semver"
+)
+
+// VersionStatus describes the running version compared to the latest release
+type VersionStatus int
+
+// Statuses of the running version
+const (
+	// VersionUnknown when the latest release is not known yet
+	VersionUnknown VersionStatus = iota
+	// VersionDevBuild when the running version is not a semantic version, e.g. undefined
+	VersionDevBuild
+	VersionUpToDate
+	VersionOutdated
+	VersionAhead
+)
+
+// GetVersionStatus compares the running version with the latest release
+func GetVersionStatus(current, latest string) VersionStatus {
+	cv, err := semver.Parse(current)
+	if err != nil {
+		return VersionDevBuild
+	}
+	lv, err := semver.Parse(latest)
+	if err != nil {
+		return VersionUnknown
+	}
+	switch cv.Compare(lv) {
+	case -1:
+		return VersionOutdated
+	case 1:
+		return VersionAhead
+	}
+	return VersionUpToDate
+}
+
+// Message returns the user facing message for the status
+func (s VersionStatus) Message(latest string) string {
+	switch s {
+	case VersionDevBuild:
+		if latest == "" {
+			return "You are running a development build"
+		}
+		return fmt.Sprintf("You are running a development build, the latest release is %s", latest)
+	case VersionUpToDate:
+		return "Your app is up to date 👍"
+	case VersionOutdated:
+		return fmt.Sprintf("New version %s of the app is available!", latest)
+	case VersionAhead:
+		return fmt.Sprintf("You are ahead of the latest release %s", latest)
+	}
+	return "Latest release is not known yet"
+}
+
+// DescribeVersion returns the version with its pre-release or development build state
+func DescribeVersion(version string) string {
+	v, err := semver.Parse(version)
+	if err != nil {
+		return version + " (development build)"
+	}
+	if v.IsPreRelease() {
+		return version + " (pre-release)"
+	}
+	return version
+}
+
+// releaseVersion returns the version of the release from its tag, or from
+// the download URL of the asset if the tag is not a semantic version
+func releaseVersion(tag, assetURL string) string {
+	if _, err := semver.Parse(tag); err == nil {
+		return tag
+	}
+	if v := semver.Find(assetURL); v != nil {
+		return v.Original
+	}
+	return tag
+}
@@ -0,0 +1,289 @@
+package software
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+	"github.com/abhijitWakchaure/run-flogo-app/download"
+	"github.com/abhijitWakchaure/run-flogo-app/semver"
+)
+
+// tmpSuffix is the suffix of the file being copied by copyExecutable
+const tmpSuffix = ".tmp"
+
+// KeptVersion is a previously installed version of the program kept in the versions dir
+type KeptVersion struct {
+	Version string
+	Path    string
+	SavedAt time.Time
+}
+
+// ListVersions returns the versions kept in the versions dir, the most recently
+// saved first. The temp files left by an interrupted copy are skipped.
+func ListVersions() ([]*KeptVersion, error) {
+	entries, err := os.ReadDir(config.VersionsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var versions []*KeptVersion
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".exe")
+		if e.IsDir() || !strings.HasPrefix(name, config.AppName+"-") || strings.HasSuffix(name, tmpSuffix) || download.IsPartial(name) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, &KeptVersion{
+			Version: strings.TrimPrefix(name, config.AppName+"-"),
+			Path:    filepath.Join(config.VersionsDir(), e.Name()),
+			SavedAt: info.ModTime(),
+		})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].SavedAt.After(versions[j].SavedAt)
+	})
+	return versions, nil
+}
+
+// FindVersion returns the kept version matching the given version, the
+// leading v is optional
+func FindVersion(versions []*KeptVersion, version string) *KeptVersion {
+	want, err := semver.Parse(version)
+	for _, v := range versions {
+		if v.Version == version {
+			return v
+		}
+		if err != nil {
+			continue
+		}
+		if got, err := semver.Parse(v.Version); err == nil && got.Compare(want) == 0 {
+			return v
+		}
+	}
+	return nil
+}
+
+// BinaryVersion runs the version command of the program at path and returns
+// the version printed by it
+func BinaryVersion(path string) (string, error) {
+	out, err := probe(path, "version")
+	if err != nil {
+		return "", err
+	}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(line, "#> Version:") {
+			continue
+		}
+		if v := semver.Find(line); v != nil {
+			return v.Original, nil
+		}
+		return "", fmt.Errorf("%s is a development build", path)
+	}
+	return "", fmt.Errorf("version of %s not found in its output", path)
+}
+
+// selfCheck verifies that the program at path starts and prints its version
+func selfCheck(path string) error {
+	out, err := probe(path, "version")
+	if err != nil {
+		return fmt.Errorf("%s version failed: %s\n%s", path, err.Error(), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// probe runs the program at path with the given args, with the config and
+// state dirs pointing to a temp dir so that it does not touch the files of
+// the user
+func probe(path string, args ...string) ([]byte, error) {
+	home, err := os.MkdirTemp("", config.AppName+"-probe-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(home)
+	ctx, cancel := context.WithTimeout(context.Background(), config.SelfCheckTimeout*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(),
+		"HOME="+home,
+		"USERPROFILE="+home,
+		"AppData="+home,
+		"LocalAppData="+home,
+		"XDG_CONFIG_HOME="+filepath.Join(home, ".config"),
+		"XDG_STATE_HOME="+filepath.Join(home, ".local", "state"),
+		"XDG_CACHE_HOME="+filepath.Join(home, ".cache"),
+		config.EnvConfigFile+"="+filepath.Join(home, config.SettingsFileName),
+	)
+	cmd.Dir = home
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return out, fmt.Errorf("timed out after %ds", config.SelfCheckTimeout)
+	}
+	return out, err
+}
+
+// saveInstalled copies the installed program into the versions dir, it returns
+// nil if the program is not installed yet
+func saveInstalled(dst string) (*KeptVersion, error) {
+	if _, err := os.Stat(dst); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	version, err := BinaryVersion(dst)
+	if err != nil {
+		version = "unknown-" + time.Now().Format("20060102150405")
+	}
+	name := config.AppName + "-" + version
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	kept := &KeptVersion{Version: version, Path: filepath.Join(config.VersionsDir(), name), SavedAt: time.Now()}
+	if err := os.MkdirAll(config.VersionsDir(), 0700); err != nil {
+		return nil, err
+	}
+	if err := copyExecutable(dst, kept.Path); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+// pruneVersions removes all but the keep most recently saved versions
+func pruneVersions(keep int) error {
+	versions, err := ListVersions()
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(versions); i++ {
+		if err := os.Remove(versions[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyExecutable(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + tmpSuffix
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// Rollback will install the given kept version of the program, or the most
+// recently saved one which differs from the installed version
+func Rollback(version string, keepVersions int) {
+	versions, err := ListVersions()
+	if err != nil {
+		fmt.Printf("E> Error ERR_ROLLBACK_LIST: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if len(versions) == 0 {
+		fmt.Printf("E> Error ERR_ROLLBACK_NOVERSIONS: no previous versions are kept in %s\n", config.VersionsDir())
+		os.Exit(1)
+	}
+	var target *KeptVersion
+	if version != "" {
+		target = FindVersion(versions, version)
+		if target == nil {
+			fmt.Printf("E> Error ERR_ROLLBACK_NOTFOUND: version [%s] is not kept, run %s versions to list the kept versions\n", version, config.AppName)
+			os.Exit(1)
+		}
+	} else {
+		dst, err := InstalledPath()
+		if err != nil {
+			fmt.Printf("E> Error ERR_ROLLBACK: %s\n", err.Error())
+			os.Exit(1)
+		}
+		installed, _ := BinaryVersion(dst)
+		for _, v := range versions {
+			if v.Version != installed {
+				target = v
+				break
+			}
+		}
+		if target == nil {
+			fmt.Printf("E> Error ERR_ROLLBACK_NOVERSIONS: only the installed version %s is kept\n", installed)
+			os.Exit(1)
+		}
+	}
+	if err := selfCheck(target.Path); err != nil {
+		fmt.Printf("E> Error ERR_ROLLBACK_SELFCHECK: %s\n", err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("#> Rolling back to %s\n", target.Version)
+	// Install from a copy as the kept file may be replaced or pruned by the install
+	tmp, err := os.MkdirTemp("", config.AppName+"-rollback-")
+	if err != nil {
+		fmt.Printf("E> Error ERR_ROLLBACK: %s\n", err.Error())
+		os.Exit(1)
+	}
+	defer os.RemoveAll(tmp)
+	src := filepath.Join(tmp, filepath.Base(target.Path))
+	if err := copyExecutable(target.Path, src); err != nil {
+		fmt.Printf("E> Error ERR_ROLLBACK: %s\n", err.Error())
+		os.Exit(1)
+	}
+	Install(src, "", keepVersions)
+}
+
+// PrintVersions will print the installed version and the versions kept for rollback
+func PrintVersions() {
+	dst, err := InstalledPath()
+	if err != nil {
+		fmt.Printf("E> Error ERR_VERSIONS: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		fmt.Printf("#> Installed: none (%s)\n", dst)
+	} else if installed, err := BinaryVersion(dst); err != nil {
+		fmt.Printf("#> Installed: unknown (%s)\n", dst)
+	} else {
+		fmt.Printf("#> Installed: %s (%s)\n", installed, dst)
+	}
+	versions, err := ListVersions()
+	if err != nil {
+		fmt.Printf("E> Error ERR_VERSIONS: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if len(versions) == 0 {
+		fmt.Printf("#> No previous versions are kept in %s\n", config.VersionsDir())
+		return
+	}
+	fmt.Printf("#> Versions kept for rollback in %s:\n", config.VersionsDir())
+	for i, v := range versions {
+		fmt.Printf("%d. %s (saved %s)\n", i+1, v.Version, v.SavedAt.Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,41 @@
+package software
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhijitWakchaure/run-flogo-app/config"
+)
+
+func TestListVersions(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := config.VersionsDir()
+	if err := os.MkdirAll(filepath.Join(dir, config.AppName+"-v2.0.0-dir"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		config.AppName + "-v2.1.0",
+		config.AppName + "-v2.2.0.exe",
+		config.AppName + "-v2.3.0" + tmpSuffix,
+		config.AppName + "-v2.3.0.exe" + tmpSuffix,
+		config.AppName + "-v2.4.0.part",
+		config.AppName + "-v2.4.0.part.json",
+		"other-v2.5.0",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err := ListVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, v := range versions {
+		got[v.Version] = true
+	}
+	if len(versions) != 2 || !got["v2.1.0"] || !got["v2.2.0"] {
+		t.Errorf("kept versions are %v, want v2.1.0 and v2.2.0", got)
+	}
+}